@@ -5,11 +5,12 @@ import (
 	"errors"
 	"fmt"
 	cp "github.com/mainak55512/qwe/compressor"
+	dl "github.com/mainak55512/qwe/delta"
+	ob "github.com/mainak55512/qwe/object"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 	"io"
 	"os"
-	"time"
 	"unicode"
 )
 
@@ -36,6 +37,7 @@ func CheckBinFile(filePath string) (bool, error) {
 	return false, nil
 }
 
+// Checks if both files have the same content
 func CheckBinDiff(file_one, file_two string) (bool, error) {
 	file_1, err := os.Open(file_one)
 	if err != nil {
@@ -49,94 +51,124 @@ func CheckBinDiff(file_one, file_two string) (bool, error) {
 	}
 	defer file_2.Close()
 
-	for {
-		buff1 := make([]byte, 8192)
-		buff2 := make([]byte, 8192)
+	return sameContent(file_1, file_2)
+}
 
-		_, err1 := file_1.Read(buff1)
-		_, err2 := file_2.Read(buff2)
+// Compares both readers chunk by chunk until the end
+func sameContent(reader_1, reader_2 io.Reader) (bool, error) {
+	buff1 := make([]byte, 8192)
+	buff2 := make([]byte, 8192)
+	for {
+		n1, err1 := io.ReadFull(reader_1, buff1)
+		n2, err2 := io.ReadFull(reader_2, buff2)
 
-		if err1 != nil || err2 != nil {
-			if err1 == io.EOF && err2 == io.EOF {
-				return true, nil
-			} else if err1 == io.EOF || err2 == io.EOF {
-				return false, nil
-			} else {
-				return false, fmt.Errorf("Error while comparing!")
-			}
+		if err1 != nil && !errors.Is(err1, io.EOF) && !errors.Is(err1, io.ErrUnexpectedEOF) ||
+			err2 != nil && !errors.Is(err2, io.EOF) && !errors.Is(err2, io.ErrUnexpectedEOF) {
+			return false, fmt.Errorf("Error while comparing!")
 		}
-
-		if !bytes.Equal(buff1, buff2) {
+		if !bytes.Equal(buff1[:n1], buff2[:n2]) {
 			return false, nil
 		}
+
+		// A short read means the end of that reader is reached
+		if err1 != nil || err2 != nil {
+			return err1 != nil && err2 != nil, nil
+		}
 	}
 }
 
-func RevertBinFile(filePath, fileObjID string) error {
-	commitFile := ".qwe/_object/" + fileObjID
-	src, err := os.Open(commitFile)
-	if err != nil {
-		return err
+// Restores a binary version to the file path. deltaOf is the full copy the object is a delta of,
+// empty if the object is a full copy itself.
+func RevertBinFile(root, filePath, fileObjID, deltaOf string) error {
+	if deltaOf != "" {
+		content, err := ReadVersion(root, fileObjID, deltaOf)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filePath, content, 0644)
 	}
 	dest, err := os.Create(filePath)
 	if err != nil {
 		return err
 	}
-	if _, err = io.Copy(dest, src); err != nil {
-		return err
+	defer dest.Close()
+	return cp.CopyTo(dest, utl.ObjectPath(root, fileObjID))
+}
+
+// Returns the content of a binary version, stored either as a full copy or as a delta of the full copy deltaOf
+func ReadVersion(root, objID, deltaOf string) ([]byte, error) {
+	content, err := cp.ReadFile(utl.ObjectPath(root, objID))
+	if err != nil || deltaOf == "" {
+		return content, err
 	}
-	if err = cp.DecompressFile(filePath); err != nil {
-		return err
+	source, err := cp.ReadFile(utl.ObjectPath(root, deltaOf))
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return dl.ApplyBinary(source, content)
 }
 
-func CommitBinFile(filePath, lastCommit string) (string, error) {
+// Checks if the file has the same content as the binary version, see ReadVersion.
+// Full copies are decompressed while comparing.
+func SameAsObject(root, filePath, objID, deltaOf string) (bool, error) {
 	src, err := os.Open(filePath)
 	if err != nil {
-		return "", err
+		return false, err
 	}
-	fileObjID := "_bin_" + utl.Hasher(fmt.Sprintf("%s%d", filePath, time.Now().UnixNano()))
-	target := ".qwe/_object/" + fileObjID
-	dest, err := os.Create(target)
-	if err != nil {
-		return "", err
+	defer src.Close()
+	if deltaOf != "" {
+		content, err := ReadVersion(root, objID, deltaOf)
+		if err != nil {
+			return false, err
+		}
+		return sameContent(bytes.NewReader(content), src)
 	}
 
-	lastCommittedFile, err := os.Open(".qwe/_object/" + lastCommit)
+	objFile, err := os.Open(utl.ObjectPath(root, objID))
 	if err != nil {
-		return "", err
+		return false, err
 	}
-	defer lastCommittedFile.Close()
-
-	if _, err := io.Copy(dest, lastCommittedFile); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
-		return "", err
+	defer objFile.Close()
+	content, err := cp.NewReader(objFile)
+	if err != nil {
+		return false, err
 	}
+	defer content.Close()
+	return sameContent(content, src)
+}
 
-	if err = cp.DecompressFile(target); err != nil {
-		return "", err
-	}
+// Deltas larger than this fraction of the file are not worth it, a full copy is stored instead
+const maxDeltaRatio = 0.5
 
-	isEq, err := CheckBinDiff(target, filePath)
+// Stores the binary file if it differs from the last commit, given by its object and the full copy
+// that object is a delta of. The file is stored as a delta of the full copy underlying the last commit,
+// or as a new full copy if the delta would be too large. Deltas always apply to a full copy,
+// hence every version is restored from at most two objects. Returns the object ID and the full copy
+// it is a delta of, which is empty for full copies.
+func CommitBinFile(root, filePath, lastObjID, lastDeltaOf string) (string, string, error) {
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	last, err := ReadVersion(root, lastObjID, lastDeltaOf)
+	if err != nil {
+		return "", "", err
+	}
+	if bytes.Equal(content, last) {
+		return "", "", er.NoFileOrDiff
 	}
 
-	if !isEq {
-		dest, err = os.Create(target)
-		if err != nil {
-			return "", err
+	sourceID, source := lastObjID, last
+	if lastDeltaOf != "" {
+		sourceID = lastDeltaOf
+		if source, err = cp.ReadFile(utl.ObjectPath(root, sourceID)); err != nil {
+			return "", "", err
 		}
-		if _, err := io.Copy(dest, src); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
-			return "", err
-		}
-		if err = cp.CompressFile(target); err != nil {
-			return "", err
-		}
-	} else {
-		os.Remove(target)
-		return "", er.NoFileOrDiff
 	}
-
-	return fileObjID, nil
+	if delta := dl.DiffBinary(source, content); float64(len(delta)) <= float64(len(content))*maxDeltaRatio {
+		objID, err := ob.Write(root, "", delta)
+		return objID, sourceID, err
+	}
+	objID, err := ob.Write(root, "_bin_", content)
+	return objID, "", err
 }
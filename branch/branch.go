@@ -0,0 +1,310 @@
+package branch
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	rb "github.com/mainak55512/qwe/rebase"
+	rv "github.com/mainak55512/qwe/revert"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Named line of history of a file or a group
+type Branch struct {
+	Name    string `json:"name"`
+	Commit  int    `json:"commit"` // commit id of the head, -2 for the base version of a file
+	Current bool   `json:"current"`
+}
+
+// Checks that a branch name can be given on the command line
+func validName(name string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return er.InvalidBranchName
+	}
+	return nil
+}
+
+// Returns the tracker entry of the file along with its id
+func fileEntry(root, filePath string) (tr.TrackerSchema, string, tr.Tracker, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return nil, "", tr.Tracker{}, err
+	}
+
+	// Get tracker details
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return nil, "", tr.Tracker{}, err
+	}
+	fileId := utl.Hasher(filePath)
+
+	// Check if the file is tracked
+	val, ok := tracker[fileId]
+	if !ok {
+		return nil, "", tr.Tracker{}, er.FileNotTracked
+	}
+	return tracker, fileId, val, nil
+}
+
+func saveFileTracker(root string, tracker tr.TrackerSchema) error {
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.CommitUnsuccessful
+	}
+	return tr.SaveTracker(root, 0, marshalContent)
+}
+
+// Returns the branches of the file sorted by name
+func List(root, filePath string) ([]Branch, error) {
+	_, _, val, err := fileEntry(root, filePath)
+	if err != nil {
+		return nil, err
+	}
+	var branches []Branch
+	for name, head := range val.BranchHeads() {
+		branches = append(branches, Branch{Name: name, Commit: val.CommitNumber(head), Current: name == val.CurrentBranch()})
+	}
+	sortBranches(branches)
+	return branches, nil
+}
+
+// Returns the checked out branch of the file and whether the checked out version is its head.
+// Commits made elsewhere become the new head and leave the commits after the checked out version off the branch.
+func Tip(root, filePath string) (Branch, bool, error) {
+	_, _, val, err := fileEntry(root, filePath)
+	if err != nil {
+		return Branch{}, false, err
+	}
+	name := val.CurrentBranch()
+	head := val.BranchHeads()[name]
+	return Branch{Name: name, Commit: val.CommitNumber(head), Current: true}, head == val.Current, nil
+}
+
+// Creates a branch of the file whose head is the commit, commitNumber -1 refers to the checked out version.
+// The checked out branch stays as it is.
+func Create(root, filePath, name string, commitNumber int) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	tracker, fileId, val, err := fileEntry(root, filePath)
+	if err != nil {
+		return err
+	}
+	heads := val.BranchHeads()
+	if _, ok := heads[name]; ok {
+		return er.BranchExists
+	}
+
+	head := val.Current
+	if commitNumber != -1 {
+		if commitNumber < 0 || commitNumber > len(val.Versions)-1 {
+			return er.InvalidCommitNo
+		}
+		head = val.Versions[commitNumber].UID
+	}
+	heads[name] = head
+
+	// The heads of the branches are recorded from the first branch on, the default branch included
+	val.Branches = heads
+	tracker[fileId] = val
+	return saveFileTracker(root, tracker)
+}
+
+// Deletes a branch of the file, its commits stay in the history of the file
+func Delete(root, filePath, name string) error {
+	tracker, fileId, val, err := fileEntry(root, filePath)
+	if err != nil {
+		return err
+	}
+	heads := val.BranchHeads()
+	if _, ok := heads[name]; !ok {
+		return er.InvalidBranch
+	}
+	if name == val.CurrentBranch() {
+		return er.BranchCheckedOut
+	}
+	delete(heads, name)
+	val.Branches = heads
+	tracker[fileId] = val
+	return saveFileTracker(root, tracker)
+}
+
+// Reverts the file to the head of the branch and checks the branch out, later commits of the file extend it.
+// Returns the commit id of the head, -2 for the base version.
+func Switch(root, filePath, name string) (int, error) {
+	_, _, val, err := fileEntry(root, filePath)
+	if err != nil {
+		return -1, err
+	}
+	head, ok := val.BranchHeads()[name]
+	if !ok {
+		return -1, er.InvalidBranch
+	}
+
+	commitNumber := val.CommitNumber(head)
+	if commitNumber == -2 {
+		err = rb.Rebase(root, filePath)
+	} else {
+		_, err = rv.Revert(root, commitNumber, filePath)
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	// Reverting has updated the tracker
+	tracker, fileId, val, err := fileEntry(root, filePath)
+	if err != nil {
+		return -1, err
+	}
+	val.Branches = val.BranchHeads()
+	val.Branch = name
+	tracker[fileId] = val
+	return commitNumber, saveFileTracker(root, tracker)
+}
+
+// Returns the group tracker along with the entry of the group
+func groupEntry(root, groupName string) (tr.GroupTrackerSchema, tr.GroupTracker, error) {
+
+	// Get group tracker
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return nil, tr.GroupTracker{}, err
+	}
+
+	// Check if valid group
+	gr, ok := groupTracker[utl.Hasher(groupName)]
+	if !ok {
+		return nil, tr.GroupTracker{}, er.InvalidGroup
+	}
+	return groupTracker, gr, nil
+}
+
+func saveGroupTracker(root string, groupTracker tr.GroupTrackerSchema) error {
+	marshalContent, err := json.MarshalIndent(groupTracker, "", " ")
+	if err != nil {
+		return er.CommitUnsuccessful
+	}
+	return tr.SaveTracker(root, 1, marshalContent)
+}
+
+// Returns the commit id of a group commit, -1 if there is no such commit
+func groupCommitID(gr tr.GroupTracker, versionID string) int {
+	for i, e := range gr.VersionOrder {
+		if e == versionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the branches of the group sorted by name
+func GroupList(root, groupName string) ([]Branch, error) {
+	_, gr, err := groupEntry(root, groupName)
+	if err != nil {
+		return nil, err
+	}
+	var branches []Branch
+	for name, head := range gr.BranchHeads() {
+		branches = append(branches, Branch{Name: name, Commit: groupCommitID(gr, head), Current: name == gr.CurrentBranch()})
+	}
+	sortBranches(branches)
+	return branches, nil
+}
+
+// Returns the checked out branch of the group and whether the current commit is its head, see Tip
+func GroupTip(root, groupName string) (Branch, bool, error) {
+	_, gr, err := groupEntry(root, groupName)
+	if err != nil {
+		return Branch{}, false, err
+	}
+	name := gr.CurrentBranch()
+	head := gr.BranchHeads()[name]
+	return Branch{Name: name, Commit: groupCommitID(gr, head), Current: true}, head == gr.Current, nil
+}
+
+// Creates a branch of the group whose head is the group commit, commitID -1 refers to the current commit.
+// The checked out branch stays as it is.
+func GroupCreate(root, groupName, name string, commitID int) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	groupTracker, gr, err := groupEntry(root, groupName)
+	if err != nil {
+		return err
+	}
+	heads := gr.BranchHeads()
+	if _, ok := heads[name]; ok {
+		return er.BranchExists
+	}
+
+	head := gr.Current
+	if commitID != -1 {
+		if commitID < 0 || commitID > len(gr.VersionOrder)-1 {
+			return er.InvalidCommitNo
+		}
+		head = gr.VersionOrder[commitID]
+	}
+	heads[name] = head
+
+	gr.Branches = heads
+	groupTracker[utl.Hasher(groupName)] = gr
+	return saveGroupTracker(root, groupTracker)
+}
+
+// Deletes a branch of the group, its commits stay in the history of the group
+func GroupDelete(root, groupName, name string) error {
+	groupTracker, gr, err := groupEntry(root, groupName)
+	if err != nil {
+		return err
+	}
+	heads := gr.BranchHeads()
+	if _, ok := heads[name]; !ok {
+		return er.InvalidBranch
+	}
+	if name == gr.CurrentBranch() {
+		return er.BranchCheckedOut
+	}
+	delete(heads, name)
+	gr.Branches = heads
+	groupTracker[utl.Hasher(groupName)] = gr
+	return saveGroupTracker(root, groupTracker)
+}
+
+// Reverts every file of the group to the head of the branch and checks the branch out,
+// later group commits extend it. Returns the commit id of the head.
+func GroupSwitch(root, groupName, name string) (int, error) {
+	_, gr, err := groupEntry(root, groupName)
+	if err != nil {
+		return -1, err
+	}
+	head, ok := gr.BranchHeads()[name]
+	if !ok {
+		return -1, er.InvalidBranch
+	}
+	commitID := groupCommitID(gr, head)
+	if err = rv.RevertGroup(root, groupName, commitID); err != nil {
+		return -1, err
+	}
+
+	// Reverting has updated the group tracker
+	groupTracker, gr, err := groupEntry(root, groupName)
+	if err != nil {
+		return -1, err
+	}
+	gr.Branches = gr.BranchHeads()
+	gr.Branch = name
+	groupTracker[utl.Hasher(groupName)] = gr
+	return commitID, saveGroupTracker(root, groupTracker)
+}
+
+func sortBranches(branches []Branch) {
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].Name < branches[j].Name
+	})
+}
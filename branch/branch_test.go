@@ -0,0 +1,162 @@
+package branch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	er "github.com/mainak55512/qwe/qwerror"
+	rv "github.com/mainak55512/qwe/revert"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestSwitch tests that a branch created at an older commit keeps its own head apart from the default branch
+func TestSwitch(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(content string) int {
+		t.Helper()
+		write(content)
+		_, commitID, err := cm.CommitUnit(root, "notes.txt", content)
+		if err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+		return commitID
+	}
+	expect := func(content string) {
+		t.Helper()
+		got, err := os.ReadFile(notes)
+		if err != nil || string(got) != content {
+			t.Errorf("file content = %q, %v; want %q", got, err, content)
+		}
+	}
+
+	write("v0\n")
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	commit("v1\n")
+	commit("v2\n")
+
+	if err := Create(root, "notes.txt", "bad name", -1); !errors.Is(err, er.InvalidBranchName) {
+		t.Errorf("expected InvalidBranchName, got %v", err)
+	}
+	if err := Create(root, "notes.txt", "exp", 0); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := Create(root, "notes.txt", "exp", 1); !errors.Is(err, er.BranchExists) {
+		t.Errorf("expected BranchExists, got %v", err)
+	}
+
+	if commitID, err := Switch(root, "notes.txt", "exp"); err != nil || commitID != 0 {
+		t.Fatalf("Switch() = %d, %v; want 0, nil", commitID, err)
+	}
+	expect("v1\n")
+	expCommit := commit("experiment\n")
+
+	if commitID, err := Switch(root, "notes.txt", tr.DefaultBranch); err != nil || commitID != 1 {
+		t.Fatalf("Switch() = %d, %v; want 1, nil", commitID, err)
+	}
+	expect("v2\n")
+	mainCommit := commit("v3\n")
+
+	// Reverting leaves the head of the branch, the next commit replaces it
+	if _, err := rv.Revert(root, 0, "notes.txt"); err != nil {
+		t.Fatalf("Revert() failed: %v", err)
+	}
+	if tip, atTip, err := Tip(root, "notes.txt"); err != nil || atTip || tip.Name != tr.DefaultBranch || tip.Commit != mainCommit {
+		t.Errorf("Tip() = %+v, %v, %v; want %s at commit %d not checked out", tip, atTip, err, tr.DefaultBranch, mainCommit)
+	}
+	if _, err := rv.Revert(root, -1, "notes.txt"); err != nil {
+		t.Fatalf("Revert() failed: %v", err)
+	}
+	expect("v3\n")
+	if _, atTip, err := Tip(root, "notes.txt"); err != nil || !atTip {
+		t.Errorf("Tip() = %v, %v; want the head checked out", atTip, err)
+	}
+
+	branches, err := List(root, "notes.txt")
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	want := []Branch{{Name: "exp", Commit: expCommit}, {Name: tr.DefaultBranch, Commit: mainCommit, Current: true}}
+	if len(branches) != len(want) || branches[0] != want[0] || branches[1] != want[1] {
+		t.Errorf("List() = %+v; want %+v", branches, want)
+	}
+
+	if _, err := Switch(root, "notes.txt", "exp"); err != nil {
+		t.Fatalf("Switch() failed: %v", err)
+	}
+	expect("experiment\n")
+	if err := Delete(root, "notes.txt", "exp"); !errors.Is(err, er.BranchCheckedOut) {
+		t.Errorf("expected BranchCheckedOut, got %v", err)
+	}
+	if err := Delete(root, "notes.txt", tr.DefaultBranch); err != nil {
+		t.Errorf("Delete() failed: %v", err)
+	}
+	if _, err := Switch(root, "notes.txt", tr.DefaultBranch); !errors.Is(err, er.InvalidBranch) {
+		t.Errorf("expected InvalidBranch, got %v", err)
+	}
+}
+
+// TestGroupSwitch tests that group commits extend the checked out branch of the group
+func TestGroupSwitch(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := in.GroupInit(root, "docs"); err != nil {
+		t.Fatalf("GroupInit() failed: %v", err)
+	}
+	if err := os.WriteFile(notes, []byte("v0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.StartGroupTracking(root, "docs", []string{"notes.txt"}, false); err != nil {
+		t.Fatalf("StartGroupTracking() failed: %v", err)
+	}
+	commit := func(content string) int {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		commitID, _, err := cm.CommitGroup(root, "docs", content)
+		if err != nil {
+			t.Fatalf("CommitGroup() failed: %v", err)
+		}
+		return commitID
+	}
+	first := commit("v1\n")
+	commit("v2\n")
+
+	if err := GroupCreate(root, "docs", "exp", first); err != nil {
+		t.Fatalf("GroupCreate() failed: %v", err)
+	}
+	if commitID, err := GroupSwitch(root, "docs", "exp"); err != nil || commitID != first {
+		t.Fatalf("GroupSwitch() = %d, %v; want %d, nil", commitID, err, first)
+	}
+	if got, _ := os.ReadFile(notes); string(got) != "v1\n" {
+		t.Errorf("file content = %q; want %q", got, "v1\n")
+	}
+	expCommit := commit("experiment\n")
+
+	branches, err := GroupList(root, "docs")
+	if err != nil {
+		t.Fatalf("GroupList() failed: %v", err)
+	}
+	if len(branches) != 2 || branches[0].Name != "exp" || branches[0].Commit != expCommit || !branches[0].Current || branches[1].Commit != 2 {
+		t.Errorf("GroupList() = %+v", branches)
+	}
+}
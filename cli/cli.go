@@ -1,18 +1,25 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	tw "text/tabwriter"
 
-	cm "github.com/mainak55512/qwe/commit"
+	"github.com/mainak55512/qwe/branch"
 	"github.com/mainak55512/qwe/diff"
-	in "github.com/mainak55512/qwe/initializer"
+	"github.com/mainak55512/qwe/fsck"
+	"github.com/mainak55512/qwe/gc"
+	"github.com/mainak55512/qwe/qwe"
 	er "github.com/mainak55512/qwe/qwerror"
-	rb "github.com/mainak55512/qwe/rebase"
-	rc "github.com/mainak55512/qwe/recover"
-	rv "github.com/mainak55512/qwe/revert"
+	utl "github.com/mainak55512/qwe/qweutils"
+	"github.com/mainak55512/qwe/status"
+	"github.com/mainak55512/qwe/tag"
 	tr "github.com/mainak55512/qwe/tracker"
 )
 
@@ -70,227 +77,995 @@ func helpText() {
 	w.Init(os.Stdout, 0, 0, 0, ' ', tw.TabIndent)
 	fmt.Println("Version: v0.3.1")
 	fmt.Println()
+	fmt.Println("[OPTIONS]:")
+	fmt.Fprintln(w, "qwe --repo <path> <command>\t[Run the command on the repository at path, QWE_DIR environment variable is used otherwise]")
+	fmt.Fprintln(w, "\t[Without it the nearest parent folder containing a repository is used]")
+	fmt.Fprintln(w)
+	w.Flush()
 	fmt.Println("[COMMANDS]:")
 	fmt.Fprintln(w, "qwe init\t[Initialize qwe in present directory]")
 	fmt.Fprintln(w, "qwe group-init <group name>\t[Initialize a group to track multiple files]")
 	fmt.Fprintln(w, "qwe groups\t[Get list of all groups tracked in the repository]")
 	fmt.Fprintln(w, "qwe groups <file-path>\t[Get list of all groups in which a file is tracked]")
-	fmt.Fprintln(w, "qwe track <file-path>\t[Start tracking a file]")
-	fmt.Fprintln(w, "qwe group-track <group name> <file/folder-path>...\t[Start tracking one or more files in a group or all files of a folder in a group]")
+	fmt.Fprintln(w, "qwe track [--recursive] <file/folder-path/glob>...\t[Start tracking files, all files of folders or files matching glob patterns like 'content/**/*.md']")
+	fmt.Fprintln(w, "\t[Folders are only walked into subfolders with --recursive]")
+	fmt.Fprintln(w, "qwe group-track <group name> [--recursive] [--auto] <file/folder-path/glob>...\t[Start tracking files, all files of folders or files matching glob patterns in a group]")
+	fmt.Fprintln(w, "\t[With --auto new files in the folders or matching the patterns are tracked and deleted files dropped on every group-commit]")
+	fmt.Fprintln(w, "qwe check-ignore <path>\t[Show the .qweignore rule that decides whether a path is ignored by folder and glob tracking]")
+	fmt.Fprintln(w, "qwe mv <old-file-path> <new-file-path>\t[Move a tracked file, its history follows it]")
+	fmt.Fprintln(w, "qwe untrack [--force] <file-path>\t[Stop tracking a file and forget its history, --force also removes it from its groups]")
+	fmt.Fprintln(w, "qwe group-untrack <group name> <file-path>\t[Remove a file from all commits of a group, the file stays tracked]")
+	fmt.Fprintln(w, "qwe group-delete <group name>\t[Delete a group and its commits, its files stay tracked]")
+	fmt.Fprintln(w, "qwe repack [--interval <number>]\t[Store full snapshots every few versions of text files so old histories reconstruct quickly]")
+	fmt.Fprintln(w, "\t[--interval sets after how many versions commits store a snapshot, 50 by default]")
+	fmt.Fprintln(w, "qwe status [--json]\t[Show which tracked files are modified, unchanged or deleted since their current version]")
+	fmt.Fprintln(w, "qwe group-status <group name> [--json]\t[Show the state of the files of a group since its current commit and the new files matching its folders and patterns]")
 	fmt.Fprintln(w, "qwe list <file-path>\t[Get list of all commits on the file]")
 	fmt.Fprintln(w, "qwe group-list <group name>\t[Get list of all commits on the group]")
 	fmt.Fprintln(w, "qwe commit <file-path> \"<commit message>\"\t[Commit current version of the file to the version control]")
 	fmt.Fprintln(w, "qwe group-commit <group name> \"<commit message>\"\t[Commit current version of all the files tracked in the group]")
-	fmt.Fprintln(w, "qwe revert <file-path>\t[Revert the file to the last committed version]")
+	fmt.Fprintln(w, "qwe revert <file-path>\t[Revert the file to the last committed version of its branch]")
 	fmt.Fprintln(w, "qwe revert <file-path> <commit-id>\t[Revert the file to a previous version]")
 	fmt.Fprintln(w, "qwe group-revert <group name> <commit-id>\t[Revert all the files tracked in the group to a previous version]")
+	fmt.Fprintln(w, "qwe branch <file-path>\t[List the branches of the file, the checked out one is marked with '*']")
+	fmt.Fprintln(w, "qwe branch <file-path> <branch name> [<commit-id>]\t[Create a branch of the file at a commit, the checked out version by default]")
+	fmt.Fprintln(w, "qwe branch --delete <file-path> <branch name>\t[Delete a branch of the file, its commits are kept]")
+	fmt.Fprintln(w, "qwe switch <file-path> <branch name>\t[Revert the file to the head of a branch, later commits extend that branch]")
+	fmt.Fprintln(w, "qwe group-branch <group name> [<branch name> [<commit-id>]]\t[List or create branches of the group]")
+	fmt.Fprintln(w, "qwe group-branch --delete <group name> <branch name>\t[Delete a branch of the group, its commits are kept]")
+	fmt.Fprintln(w, "qwe group-switch <group name> <branch name>\t[Revert all the files of the group to the head of a branch, later group commits extend that branch]")
+	fmt.Fprintln(w, "qwe tag <file-path> [<commit-id> <tag name>]\t[List the tags of the file or tag a commit, tags can be given wherever a commit-id of the file is expected]")
+	fmt.Fprintln(w, "qwe tag --delete <file-path> <tag name>\t[Delete a tag of the file, the commit is kept]")
+	fmt.Fprintln(w, "qwe group-tag <group name> [<commit-id> <tag name>]\t[List the tags of the group or tag a group commit]")
+	fmt.Fprintln(w, "qwe group-tag --delete <group name> <tag name>\t[Delete a tag of the group, the commit is kept]")
+	fmt.Fprintln(w, "qwe merge [--force] <file-path> <commit-id-1> <commit-id-2>\t[Merge the changes of two commits since their common ancestor into the file, conflicts are marked in the file, --force overwrites uncommitted changes]")
 	fmt.Fprintln(w, "qwe current <file-path>\t[Get current commit details of the file]")
 	fmt.Fprintln(w, "qwe group-current <group name>\t[Get current commit details of the group]")
 	fmt.Fprintln(w, "qwe group-current <group name> <commit-id>\t[Get commit details of a specific commit of the group]")
 	fmt.Fprintln(w, "qwe recover <file-path>\t[Restore deleted file if earlier tracked]")
 	fmt.Fprintln(w, "qwe rebase <file-path>\t[Revert back to base version of the file]")
+	fmt.Fprintln(w, "qwe migrate\t[Upgrade a repository created by an older version of qwe]")
+	fmt.Fprintln(w, "qwe fsck [--json]\t[Check the integrity of the repository, --json prints a machine-readable report]")
+	fmt.Fprintln(w, "qwe gc [--dry-run]\t[Remove objects no longer referenced by any commit, --dry-run only lists them]")
 	fmt.Fprintln(w, "qwe diff <file-path>\t[Shows difference between latest uncommitted version and latest committed version]")
 	fmt.Fprintln(w, "qwe diff <file-path> <commit-id-1> <commit-id-2>\t[Shows difference between two commits]")
 	fmt.Fprintln(w, "qwe diff <file-path> uncommitted <commit-id>\t[Shows difference between latest uncommitted version and commit-id version]")
+	fmt.Fprintln(w, "qwe diff --format=unified [--context=<lines>] <file-path> [<commit-id-1> <commit-id-2>]\t[Shows the difference as a unified diff that can be used with patch]")
 	fmt.Fprintln(w)
 	w.Flush()
 }
 
+// Separates the options of the diff command from its positional arguments
+func parseDiffFlags(args []string) ([]string, string, int, error) {
+	var positional []string
+	format := ""
+	context := diff.DefaultContext
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.TrimPrefix(arg, "--format=")
+			if format != "unified" {
+				return nil, "", 0, er.CLIDiffFlagErr
+			}
+		case strings.HasPrefix(arg, "--context="):
+			lines, err := strconv.Atoi(strings.TrimPrefix(arg, "--context="))
+			if err != nil || lines < 0 {
+				return nil, "", 0, er.CLIDiffFlagErr
+			}
+			context = lines
+		case strings.HasPrefix(arg, "--"):
+			return nil, "", 0, er.CLIDiffFlagErr
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return positional, format, context, nil
+}
+
+// Prints a tabular view of key-value rows
+func printDetails(rows ...string) {
+	w := new(tw.Writer)
+	w.Init(os.Stdout, 0, 0, 0, ' ', tw.TabIndent)
+	for _, row := range rows {
+		fmt.Fprintln(w, row)
+	}
+	w.Flush()
+}
+
 /*
 Handles command line arguments like init, track, commit, revert etc.
 */
 func HandleArgs() error {
-	command_list := os.Args[1:]
+	repoPath, command_list, err := parseRepoFlag(os.Args[1:])
+	if err != nil {
+		return err
+	}
 
 	if len(command_list) == 0 {
 		helpText()
-	} else {
+		return nil
+	}
 
-		switch command_list[0] {
-		case "init":
-			{
-				if len(command_list) != 1 {
-					return er.CLIInitErr
-				}
-				if err := in.Init(); err != nil {
-					return err
-				}
+	if command_list[0] == "init" {
+		if len(command_list) != 1 {
+			return er.CLIInitErr
+		}
+		if repoPath == "" {
+			repoPath = "."
+		}
+		if _, err := qwe.Init(repoPath); err != nil {
+			return err
+		}
+		fmt.Println("QWE initiated")
+		return nil
+	}
+
+	if !knownCommands[command_list[0]] {
+		helpText()
+		return nil
+	}
+
+	repo, err := openRepo(repoPath)
+	if err != nil {
+		return err
+	}
+
+	// Interrupted commands may have left the trackers half-written, they are repaired before anything reads them
+	repairs, err := repo.Repair()
+	if err != nil {
+		return err
+	}
+	for _, description := range repairs {
+		fmt.Println("Repaired repository:", description)
+	}
+
+	// Repositories created by older versions of qwe are upgraded once before running any command
+	if command_list[0] != "migrate" {
+		applied, err := repo.Migrate()
+		if err != nil {
+			return err
+		}
+		for _, description := range applied {
+			fmt.Println("Migrated repository:", description)
+		}
+	}
+
+	// File paths are given relative to the working directory, the repository expects them relative to its root
+	if command_list, err = relativeFileArgs(repo, command_list); err != nil {
+		return err
+	}
+
+	switch command_list[0] {
+	case "group-init":
+		{
+			if len(command_list) != 2 {
+				return er.CLIGrpInitErr
 			}
-		case "group-init":
-			{
-				if len(command_list) != 2 {
-					return er.CLIGrpInitErr
-				}
-				if err := in.GroupInit(command_list[1]); err != nil {
-					return err
-				}
+			if err := repo.GroupInit(command_list[1]); err != nil {
+				return err
 			}
-		case "track":
-			{
-				if len(command_list) != 2 {
-					return er.CLITrackErr
-				}
-				if _, err := tr.StartTracking(command_list[1]); err != nil {
-					return err
-				}
+			fmt.Println("Started tracking group", command_list[1])
+		}
+	case "track":
+		{
+			paths, options, ok := optionArgs(command_list[1:], "--recursive")
+			if !ok || len(paths) == 0 {
+				return er.CLITrackErr
 			}
-		case "groups":
-			{
-				if len(command_list) < 1 || len(command_list) > 2 {
-					return er.GrpNameListErr
-				}
-				if len(command_list) == 1 {
-					if err := cm.GroupNameList(""); err != nil {
-						return err
-					}
-				} else if len(command_list) == 2 {
-					if err := cm.GroupNameList(command_list[1]); err != nil {
-						return err
+			trackedFiles, err := repo.TrackPaths(paths, options["--recursive"])
+			if err != nil {
+				return err
+			}
+			for _, filePath := range trackedFiles {
+				fmt.Println("Started tracking", filePath)
+			}
+		}
+	case "groups":
+		{
+			if len(command_list) < 1 || len(command_list) > 2 {
+				return er.GrpNameListErr
+			}
+			var groupNames []string
+			if len(command_list) == 1 {
+				groupNames, err = repo.Groups()
+			} else {
+				groupNames, err = repo.GroupsOf(command_list[1])
+			}
+			if err != nil {
+				return err
+			}
+			if len(groupNames) == 0 && len(command_list) == 2 {
+				fmt.Println("File is not associated with any group!")
+			}
+			for _, groupName := range groupNames {
+				fmt.Println(groupName)
+			}
+		}
+	case "group-track":
+		{
+			if len(command_list) < 2 {
+				return er.CLIGrpTrackErr
+			}
+			paths, options, ok := optionArgs(command_list[2:], "--recursive", "--auto")
+			if !ok || len(paths) == 0 {
+				return er.CLIGrpTrackErr
+			}
+			trackedFiles, err := repo.GroupTrack(command_list[1], paths, options["--recursive"], options["--auto"])
+			if err != nil {
+				return err
+			}
+			for _, filePath := range trackedFiles {
+				fmt.Println("Started tracking", filePath, "for group", command_list[1])
+			}
+		}
+	case "commit":
+		{
+			if len(command_list) != 3 {
+				return er.CLICommitErr
+			}
+			commitID, tip, atTip, err := repo.CommitOnBranch(command_list[1], command_list[2])
+			if err != nil {
+				return err
+			}
+			if !atTip {
+				warnOffTip("branch", command_list[1], tip)
+			}
+			fmt.Println("Committed", command_list[1], "successfully with commit id", commitID)
+		}
+	case "group-commit":
+		{
+			if len(command_list) != 3 {
+				return er.CLIGrpCommitErr
+			}
+			commitID, changes, tip, atTip, err := repo.GroupCommitOnBranch(command_list[1], command_list[2])
+			if err != nil {
+				return err
+			}
+			if !atTip {
+				warnOffTip("group-branch", command_list[1], tip)
+			}
+			for _, filePath := range changes.Added {
+				fmt.Println("Started tracking", filePath, "for group", command_list[1])
+			}
+			for _, filePath := range changes.Removed {
+				fmt.Println("Removed deleted file", filePath, "from group", command_list[1])
+			}
+			fmt.Println("Successfully committed to group", command_list[1], "with commit id", commitID)
+		}
+	case "list":
+		{
+			if len(command_list) != 2 {
+				return er.CLIListErr
+			}
+			versions, err := repo.Log(command_list[1])
+			if err != nil {
+				return err
+			}
+			renames, err := repo.Renames(command_list[1])
+			if err != nil {
+				return err
+			}
+
+			// Print commitID, commit message and time stamp for each entry, moves of the file in between
+			printRenames := func(commits int) {
+				for _, rename := range renames {
+					if rename.Commits == commits {
+						printDetails(fmt.Sprintf("\nMoved:\t%s -> %s\nTime Stamp:\t%s\n", rename.From, rename.To, rename.TimeStamp))
 					}
 				}
 			}
-		case "group-track":
-			{
-				if len(command_list) < 3 {
-					return er.CLIGrpTrackErr
-				}
-				if err := tr.StartGroupTracking(command_list[1], command_list[2:]); err != nil {
-					return err
-				}
+			commitIDs := make(map[string]int)
+			for i, e := range versions {
+				commitIDs[e.UID] = i
 			}
-		case "commit":
-			{
-				if len(command_list) != 3 {
-					return er.CLICommitErr
+			for i, e := range versions {
+				printRenames(i)
+				parent := "base"
+				if id, ok := commitIDs[e.Parent]; ok {
+					parent = strconv.Itoa(id)
 				}
-				if _, _, err := cm.CommitUnit(command_list[1], command_list[2]); err != nil {
+				printDetails(fmt.Sprintf("\nID:\t%d\nParent:\t%s\nCommit Message:\t%s\nTime Stamp:\t%s\n", i, parent, e.CommitMessage, e.TimeStamp))
+			}
+			printRenames(len(versions))
+		}
+	case "group-list":
+		{
+			if len(command_list) != 2 {
+				return er.CLIGrpListErr
+			}
+			versions, err := repo.GroupLog(command_list[1])
+			if err != nil {
+				return err
+			}
+			var rows []string
+			for i, e := range versions {
+				rows = append(rows, fmt.Sprintf("\nID:\t%d\nCommit Message:\t%s\n", i, e.CommitMessage))
+			}
+			printDetails(rows...)
+		}
+	case "revert":
+		{
+			if len(command_list) != 3 && len(command_list) != 2 {
+				return er.CLIRevertErr
+			}
+			var commitNumber int
+			if len(command_list) == 3 {
+				commitNumber, err = repo.ResolveCommit(command_list[1], command_list[2])
+				if err != nil {
 					return err
 				}
+			} else {
+				commitNumber = -1
 			}
-		case "group-commit":
-			{
-				if len(command_list) != 3 {
-					return er.CLIGrpCommitErr
+			commitNumber, err := repo.Revert(command_list[1], commitNumber)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Successfully reverted", command_list[1], "back to commit", commitNumber)
+		}
+	case "group-revert":
+		{
+			if len(command_list) != 3 {
+				return er.CLIGrpRevertErr
+			}
+			commitNumber, err := repo.GroupResolveCommit(command_list[1], command_list[2])
+			if err != nil {
+				return err
+			}
+			if err := repo.GroupRevert(command_list[1], commitNumber); err != nil {
+				return err
+			}
+			fmt.Println("Successfully reverted group", command_list[1], "back to commit", commitNumber)
+		}
+	case "diff":
+		{
+			args, format, context, err := parseDiffFlags(command_list[1:])
+			if err != nil {
+				return err
+			}
+			if len(args) != 1 && len(args) != 3 {
+				return er.CLIDiffErr
+			}
+			commitID1, commitID2 := "", ""
+			if len(args) == 3 {
+				commitID1, commitID2 = args[1], args[2]
+			}
+			if format == "unified" {
+				hunks, err := repo.UnifiedDiff(args[0], commitID1, commitID2, context)
+				if err != nil {
+					return err
 				}
-				if err := cm.CommitGroup(command_list[1], command_list[2]); err != nil {
+				fmt.Print(diff.FormatUnified("a/"+args[0], "b/"+args[0], hunks))
+			} else {
+				result, err := repo.Diff(args[0], commitID1, commitID2)
+				if err != nil {
 					return err
 				}
+				printDiff(result)
+			}
+		}
+	case "branch", "group-branch":
+		{
+			args, options, ok := optionArgs(command_list[1:], "--delete")
+			if !ok || len(args) < 1 || len(args) > 3 || options["--delete"] && len(args) != 2 {
+				return er.CLIBranchErr
 			}
-		case "list":
-			{
-				if len(command_list) != 2 {
-					return er.CLIListErr
+			group := command_list[0] == "group-branch"
+			switch {
+			case options["--delete"]:
+				if group {
+					err = repo.GroupDeleteBranch(args[0], args[1])
+				} else {
+					err = repo.DeleteBranch(args[0], args[1])
 				}
-				if err := cm.GetCommitList(command_list[1]); err != nil {
+				if err != nil {
 					return err
 				}
-			}
-		case "group-list":
-			{
-				if len(command_list) != 2 {
-					return er.CLIGrpListErr
+				fmt.Println("Deleted branch", args[1], "of", args[0])
+			case len(args) == 1:
+				var branches []branch.Branch
+				if group {
+					branches, err = repo.GroupBranches(args[0])
+				} else {
+					branches, err = repo.Branches(args[0])
 				}
-				if err := cm.GetGroupCommitList(command_list[1]); err != nil {
+				if err != nil {
 					return err
 				}
-			}
-		case "revert":
-			{
-				if len(command_list) != 3 && len(command_list) != 2 {
-					return er.CLIRevertErr
-				}
-				var commitNumber int
-				var err error
-				if len(command_list) == 3 {
-					commitNumber, err = strconv.Atoi(command_list[2])
+				printBranches(branches)
+			default:
+				commitNumber := -1
+				if len(args) == 3 {
+					if group {
+						commitNumber, err = repo.GroupResolveCommit(args[0], args[2])
+					} else {
+						commitNumber, err = repo.ResolveCommit(args[0], args[2])
+					}
 					if err != nil {
-						return er.InvalidCommitNo
+						return err
 					}
+				}
+				if group {
+					err = repo.GroupCreateBranch(args[0], args[1], commitNumber)
 				} else {
-					commitNumber = -1
+					err = repo.CreateBranch(args[0], args[1], commitNumber)
 				}
-				if err := rv.Revert(commitNumber, command_list[1]); err != nil {
+				if err != nil {
 					return err
 				}
+				fmt.Println("Created branch", args[1], "of", args[0])
+			}
+		}
+	case "tag", "group-tag":
+		{
+			args, options, ok := optionArgs(command_list[1:], "--delete")
+			if !ok || options["--delete"] && len(args) != 2 || !options["--delete"] && len(args) != 1 && len(args) != 3 {
+				return er.CLITagErr
 			}
-		case "group-revert":
-			{
-				if len(command_list) != 3 {
-					return er.CLIGrpRevertErr
+			group := command_list[0] == "group-tag"
+			switch {
+			case options["--delete"]:
+				if group {
+					err = repo.GroupDeleteTag(args[0], args[1])
+				} else {
+					err = repo.DeleteTag(args[0], args[1])
 				}
-				commitNumber, err := strconv.Atoi(command_list[2])
 				if err != nil {
-					return er.InvalidCommitNo
-				}
-				if err := rv.RevertGroup(command_list[1], commitNumber); err != nil {
 					return err
 				}
-			}
-		case "diff":
-			{
-				if len(command_list) != 2 && len(command_list) != 4 {
-					return er.CLIDiffErr
-				} else if len(command_list) == 4 {
-					if err := diff.Diff(command_list[1], command_list[2], command_list[3]); err != nil {
-						return err
-					}
+				fmt.Println("Deleted tag", args[1], "of", args[0])
+			case len(args) == 1:
+				var tags []tag.Tag
+				if group {
+					tags, err = repo.GroupTags(args[0])
 				} else {
-					if err := diff.Diff(command_list[1], "", ""); err != nil {
-						return err
-					}
+					tags, err = repo.Tags(args[0])
 				}
-			}
-		case "current":
-			{
-				if len(command_list) != 2 {
-					return er.CLICurrentErr
+				if err != nil {
+					return err
 				}
-				if err := cm.CurrentCommit(command_list[1]); err != nil {
+				printTags(tags)
+			default:
+				if group {
+					err = repo.GroupCreateTag(args[0], args[1], args[2])
+				} else {
+					err = repo.CreateTag(args[0], args[1], args[2])
+				}
+				if err != nil {
 					return err
 				}
+				fmt.Println("Tagged", args[0], "at", args[1], "as", args[2])
 			}
-		case "group-current":
-			{
-				if len(command_list) != 2 && len(command_list) != 3 {
-					return er.CLIGrpCurrentErr
-				}
-				if len(command_list) == 2 {
-					if err := cm.GroupCommitDetails(command_list[1], -1); err != nil {
-						return err
-					}
-				} else if len(command_list) == 3 {
-					commitNumber, err := strconv.Atoi(command_list[2])
-					if err != nil {
-						return er.InvalidCommitNo
-					}
-					if err := cm.GroupCommitDetails(command_list[1], commitNumber); err != nil {
-						return err
-					}
+		}
+	case "switch", "group-switch":
+		{
+			if len(command_list) != 3 {
+				return er.CLISwitchErr
+			}
+			var commitID int
+			if command_list[0] == "group-switch" {
+				commitID, err = repo.GroupSwitch(command_list[1], command_list[2])
+			} else {
+				commitID, err = repo.Switch(command_list[1], command_list[2])
+			}
+			if err != nil {
+				return err
+			}
+			if commitID == -2 {
+				fmt.Println("Switched", command_list[1], "to branch", command_list[2], "at base version")
+			} else {
+				fmt.Println("Switched", command_list[1], "to branch", command_list[2], "at commit", commitID)
+			}
+		}
+	case "merge":
+		{
+			args, options, ok := optionArgs(command_list[1:], "--force")
+			if !ok || len(args) != 3 {
+				return er.CLIMergeErr
+			}
+			commitA, err := repo.ResolveCommit(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			commitB, err := repo.ResolveCommit(args[0], args[2])
+			if err != nil {
+				return err
+			}
+			result, err := repo.Merge(args[0], commitA, commitB, options["--force"])
+			if err != nil {
+				return err
+			}
+			ancestor := "base version"
+			if result.Ancestor != -2 {
+				ancestor = fmt.Sprintf("commit %d", result.Ancestor)
+			}
+			fmt.Printf("Merged commits %d and %d of %s from their common ancestor %s\n", commitA, commitB, args[0], ancestor)
+			if result.Conflicts > 0 {
+				fmt.Printf("%d conflicts found\n", result.Conflicts)
+				return er.MergeConflict
+			}
+		}
+	case "current":
+		{
+			if len(command_list) != 2 {
+				return er.CLICurrentErr
+			}
+			commitID, details, err := repo.Current(command_list[1])
+			if err != nil {
+				return err
+			}
+
+			// If the current checked out version is a base file, then print the base details
+			if commitID == -2 {
+				printDetails("\nCurrent Commit ID:\tbase\nCommit Message:\tBase version")
+			} else {
+				printDetails(fmt.Sprintf("\nCurrent Commit ID:\t%d\nCommit Message:\t%s", commitID, details.CommitMessage))
+			}
+		}
+	case "group-current":
+		{
+			if len(command_list) != 2 && len(command_list) != 3 {
+				return er.CLIGrpCurrentErr
+			}
+			commitNumber := -1
+			if len(command_list) == 3 {
+				commitNumber, err = repo.GroupResolveCommit(command_list[1], command_list[2])
+				if err != nil {
+					return err
 				}
 			}
-		case "recover":
-			{
-				if len(command_list) != 2 {
-					return er.CLIRecoverErr
+			commitID, details, err := repo.GroupCurrent(command_list[1], commitNumber)
+			if err != nil {
+				return err
+			}
+			printGroupDetails(command_list[1], commitID, details)
+		}
+	case "recover":
+		{
+			if len(command_list) != 2 {
+				return er.CLIRecoverErr
+			}
+			if err := repo.Recover(command_list[1]); err != nil {
+				return err
+			}
+			fmt.Println("Successfully recovered", command_list[1])
+		}
+	case "migrate":
+		{
+			if len(command_list) != 1 {
+				return er.CLIMigrateErr
+			}
+			applied, err := repo.Migrate()
+			if err != nil {
+				return err
+			}
+			if len(applied) == 0 {
+				fmt.Println("Repository is up to date")
+			}
+			for _, description := range applied {
+				fmt.Println("Migrated repository:", description)
+			}
+		}
+	case "fsck":
+		{
+			if len(command_list) > 2 || len(command_list) == 2 && command_list[1] != "--json" {
+				return er.CLIFsckErr
+			}
+			report, err := repo.Fsck()
+			if err != nil {
+				return err
+			}
+			if len(command_list) == 2 {
+				out, err := json.MarshalIndent(report, "", " ")
+				if err != nil {
+					return err
 				}
-				if err := rc.Recover(command_list[1]); err != nil {
+				fmt.Println(string(out))
+			} else {
+				printFsckReport(report)
+			}
+			if !report.Healthy() {
+				return er.RepoCorrupted
+			}
+		}
+	case "status", "group-status":
+		{
+			args, options, ok := optionArgs(command_list[1:], "--json")
+			if !ok || command_list[0] == "status" && len(args) != 0 || command_list[0] == "group-status" && len(args) != 1 {
+				return er.CLIStatusErr
+			}
+			var entries []status.Entry
+			if command_list[0] == "status" {
+				entries, err = repo.Status()
+			} else {
+				entries, err = repo.GroupStatus(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			if options["--json"] {
+				out, err := json.MarshalIndent(entries, "", " ")
+				if err != nil {
 					return err
 				}
+				fmt.Println(string(out))
+			} else {
+				printStatus(entries)
 			}
-		case "rebase":
-			{
-				if len(command_list) != 2 {
-					return er.CLIRebaseErr
+		}
+	case "repack":
+		{
+			interval := 0
+			switch {
+			case len(command_list) == 3 && command_list[1] == "--interval":
+				if interval, err = strconv.Atoi(command_list[2]); err != nil || interval <= 0 {
+					return er.InvalidInterval
 				}
-				if err := rb.Rebase(command_list[1]); err != nil {
-					return err
+			case len(command_list) != 1:
+				return er.CLIRepackErr
+			}
+			result, err := repo.Repack(interval)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Added %d keyframes to %d files\n", result.Keyframes, result.Files)
+		}
+	case "gc":
+		{
+			if len(command_list) > 2 || len(command_list) == 2 && command_list[1] != "--dry-run" {
+				return er.CLIGcErr
+			}
+			dryRun := len(command_list) == 2
+			result, err := repo.GC(dryRun)
+			if err != nil {
+				return err
+			}
+			action, summary := "Removed", "Reclaimed"
+			if dryRun {
+				action, summary = "Would remove", "Would reclaim"
+			}
+			for _, removed := range result.Removed {
+				fmt.Println(action, removed)
+			}
+			fmt.Printf("%s %d bytes from %d objects\n", summary, result.ReclaimedBytes, len(result.Removed))
+		}
+	case "check-ignore":
+		{
+			if len(command_list) != 2 {
+				return er.CLICheckIgnoreErr
+			}
+			rule, err := repo.CheckIgnore(command_list[1])
+			if err != nil {
+				return err
+			}
+			switch {
+			case rule == nil:
+				fmt.Println(command_list[1], "is not ignored")
+			case rule.Negate:
+				fmt.Printf("%s is not ignored, re-included by %s:%d: %s\n", command_list[1], rule.Source, rule.Line, rule.Pattern)
+			default:
+				fmt.Printf("%s is ignored by %s:%d: %s\n", command_list[1], rule.Source, rule.Line, rule.Pattern)
+			}
+		}
+	case "mv":
+		{
+			if len(command_list) != 3 {
+				return er.CLIMvErr
+			}
+			if err := repo.Move(command_list[1], command_list[2]); err != nil {
+				return err
+			}
+			fmt.Println("Moved", command_list[1], "to", command_list[2])
+		}
+	case "untrack":
+		{
+			var files []string
+			force := false
+			for _, arg := range command_list[1:] {
+				if arg == "--force" {
+					force = true
+				} else {
+					files = append(files, arg)
 				}
 			}
-		default:
-			{
-				helpText()
+			if len(files) != 1 || len(command_list) > 3 {
+				return er.CLIUntrackErr
 			}
+			result, err := repo.Untrack(files[0], force)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Stopped tracking", files[0])
+			printPruned(result)
+		}
+	case "group-untrack":
+		{
+			if len(command_list) != 3 {
+				return er.CLIGrpUntrackErr
+			}
+			if err := repo.GroupUntrack(command_list[1], command_list[2]); err != nil {
+				return err
+			}
+			fmt.Println("Removed", command_list[2], "from group", command_list[1])
+		}
+	case "group-delete":
+		{
+			if len(command_list) != 2 {
+				return er.CLIGrpDeleteErr
+			}
+			if err := repo.GroupDelete(command_list[1]); err != nil {
+				return err
+			}
+			fmt.Println("Deleted group", command_list[1])
+		}
+	case "rebase":
+		{
+			if len(command_list) != 2 {
+				return er.CLIRebaseErr
+			}
+			if err := repo.Rebase(command_list[1]); err != nil {
+				return err
+			}
+			fmt.Println("Successfully reverted", command_list[1], "back to base version")
 		}
 	}
 	return nil
 }
+
+// Extracts the '--repo <path>' or '--repo=<path>' option given before the command
+func parseRepoFlag(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+	if args[0] == "--repo" {
+		if len(args) < 2 || args[1] == "" {
+			return "", nil, er.CLIRepoFlagErr
+		}
+		return args[1], args[2:], nil
+	}
+	if strings.HasPrefix(args[0], "--repo=") {
+		repoPath := strings.TrimPrefix(args[0], "--repo=")
+		if repoPath == "" {
+			return "", nil, er.CLIRepoFlagErr
+		}
+		return repoPath, args[1:], nil
+	}
+	return "", args, nil
+}
+
+// Opens the repository given with --repo or QWE_DIR, otherwise the nearest one containing the working directory
+func openRepo(repoPath string) (*qwe.Repository, error) {
+	if repoPath == "" {
+		repoPath = os.Getenv("QWE_DIR")
+	}
+	if repoPath != "" {
+		return qwe.Open(repoPath)
+	}
+	return qwe.Discover(".")
+}
+
+// Separates the given options from the path arguments, reports false for any other option
+func optionArgs(args []string, options ...string) ([]string, map[string]bool, bool) {
+	var paths []string
+	set := make(map[string]bool)
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			paths = append(paths, arg)
+		} else if slices.Contains(options, arg) {
+			set[arg] = true
+		} else {
+			return nil, nil, false
+		}
+	}
+	return paths, set, true
+}
+
+// Rewrites the file path arguments of the command relative to the repository root
+func relativeFileArgs(repo *qwe.Repository, command_list []string) ([]string, error) {
+	args := append([]string{}, command_list...)
+	convert := func(idx int) error {
+		if idx >= len(args) || strings.HasPrefix(args[idx], "--") {
+			return nil
+		}
+		absPath, err := filepath.Abs(args[idx])
+		if err != nil {
+			return err
+		}
+		if utl.FolderExists(absPath) {
+			// Folders are canonicalized through an entry inside them, the repository root itself is no valid file
+			entry, err := utl.Canonical(repo.Root(), filepath.Join(absPath, "_"))
+			if err != nil {
+				return err
+			}
+			args[idx] = path.Dir(entry)
+			return nil
+		}
+		rel, err := utl.Canonical(repo.Root(), absPath)
+		if err != nil {
+			return err
+		}
+		args[idx] = rel
+		return nil
+	}
+
+	switch args[0] {
+	case "groups", "check-ignore", "commit", "list", "revert", "current", "recover", "rebase", "switch":
+		return args, convert(1)
+	case "group-untrack":
+		return args, convert(2)
+	case "mv":
+		if err := convert(1); err != nil {
+			return nil, err
+		}
+		return args, convert(2)
+	case "track":
+		for i := 1; i < len(args); i++ {
+			if err := convert(i); err != nil {
+				return nil, err
+			}
+		}
+	case "group-track":
+		for i := 2; i < len(args); i++ {
+			if err := convert(i); err != nil {
+				return nil, err
+			}
+		}
+	case "diff", "untrack", "branch", "tag", "merge":
+		// The file path is the first argument which is not an option
+		for i := 1; i < len(args); i++ {
+			if !strings.HasPrefix(args[i], "--") {
+				return args, convert(i)
+			}
+		}
+	}
+	return args, nil
+}
+
+// Commands that operate on an existing repository
+var knownCommands = map[string]bool{
+	"group-init":    true,
+	"track":         true,
+	"groups":        true,
+	"group-track":   true,
+	"commit":        true,
+	"group-commit":  true,
+	"list":          true,
+	"group-list":    true,
+	"revert":        true,
+	"group-revert":  true,
+	"diff":          true,
+	"current":       true,
+	"group-current": true,
+	"recover":       true,
+	"rebase":        true,
+	"migrate":       true,
+	"fsck":          true,
+	"gc":            true,
+	"repack":        true,
+	"status":        true,
+	"group-status":  true,
+	"untrack":       true,
+	"group-delete":  true,
+	"group-untrack": true,
+	"mv":            true,
+	"check-ignore":  true,
+	"branch":        true,
+	"group-branch":  true,
+	"switch":        true,
+	"group-switch":  true,
+	"merge":         true,
+	"tag":           true,
+	"group-tag":     true,
+}
+
+// Prints the line by line view of a diff result
+func printDiff(result diff.Result) {
+	if result.Binary {
+		if result.Changed {
+			fmt.Println("File content changed!")
+		} else {
+			fmt.Println("File content is same!")
+		}
+		return
+	}
+	if !result.Changed {
+		fmt.Println("No Change!")
+		return
+	}
+	fmt.Printf("===Start Diff view===\n\n")
+	for _, elem := range result.Changes {
+		fmt.Printf("- %d %s\n+ %d %s\n\n", elem.Line, elem.Prev, elem.Line, elem.Curr)
+	}
+	fmt.Printf("\n===End of Diff===\n")
+}
+
+// Prints the details of a group commit along with its associated files
+func printGroupDetails(groupName string, commitID int, details tr.GroupVersionDetails) {
+	w := new(tw.Writer)
+	w.Init(os.Stdout, 0, 0, 0, ' ', tw.TabIndent)
+	fmt.Fprintf(w, "\nName:\t %s\nCurrent Commit ID:\t %d\nCommit Message:\t %s\n", groupName, commitID, details.CommitMessage)
+	fmt.Fprintf(w, "\nAssociated files:\n")
+	for e := range details.Files {
+		fmt.Fprintf(w, "File: %s, \tCommitID: %d\n", details.Files[e].FileName, details.Files[e].CommitNumber)
+	}
+	w.Flush()
+}
+
+// Prints the problems found by fsck followed by a summary
+func printFsckReport(report fsck.Report) {
+	for _, problem := range report.Problems {
+		var subject []string
+		if problem.Group != "" {
+			subject = append(subject, "group "+problem.Group)
+		}
+		if problem.File != "" {
+			subject = append(subject, "file "+problem.File)
+		} else if problem.FileID != "" {
+			subject = append(subject, "file id "+problem.FileID)
+		}
+		if problem.Commit != nil {
+			subject = append(subject, fmt.Sprintf("commit %d", *problem.Commit))
+		}
+		if problem.Object != "" {
+			subject = append(subject, "object "+problem.Object)
+		}
+		fmt.Printf("%s: %s: %s\n", problem.Kind, strings.Join(subject, ", "), problem.Message)
+	}
+	fmt.Printf("Checked %d files, %d groups and %d objects: %d problems found\n", report.Files, report.Groups, report.Objects, len(report.Problems))
+}
+
+// Prints the state of every file, files whose path is unknown by their id
+func printStatus(entries []status.Entry) {
+	w := tw.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, entry := range entries {
+		file := entry.File
+		if file == "" {
+			file = "file id " + entry.FileID
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", entry.State, file)
+	}
+	w.Flush()
+}
+
+// Warns that a commit was not made at the head of its branch, the commits after the version it was made on
+// are no longer part of the branch unless another branch keeps them. branchCommand is the command creating branches of subject.
+func warnOffTip(branchCommand, subject string, tip branch.Branch) {
+	if tip.Commit < 0 {
+		fmt.Printf("Warning: %s was not at the head of branch %s, the commit is based on the checked out version and becomes the head of the branch\n", subject, tip.Name)
+		return
+	}
+	fmt.Printf("Warning: %s was not at the head of branch %s, the commit is based on the checked out version and replaces commit %d as the head of the branch\n", subject, tip.Name, tip.Commit)
+	fmt.Printf("Use 'qwe %s %s <branch name> %d' to keep a branch at commit %d\n", branchCommand, subject, tip.Commit, tip.Commit)
+}
+
+// Prints the branches with their head commit, the checked out branch is marked with '*'
+func printBranches(branches []branch.Branch) {
+	w := tw.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, b := range branches {
+		mark, head := " ", "base"
+		if b.Current {
+			mark = "*"
+		}
+		if b.Commit != -2 {
+			head = strconv.Itoa(b.Commit)
+		}
+		fmt.Fprintf(w, "%s %s\tcommit %s\n", mark, b.Name, head)
+	}
+	w.Flush()
+}
+
+// Prints the tags with the commit they name
+func printTags(tags []tag.Tag) {
+	w := tw.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, t := range tags {
+		fmt.Fprintf(w, "%s\tcommit %d\n", t.Name, t.Commit)
+	}
+	w.Flush()
+}
+
+// Prints how much space removing objects no longer referenced has reclaimed
+func printPruned(result gc.Result) {
+	if len(result.Removed) > 0 {
+		fmt.Printf("Reclaimed %d bytes from %d objects\n", result.ReclaimedBytes, len(result.Removed))
+	}
+}
@@ -1,19 +1,20 @@
 package commit
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"time"
 
+	"sort"
 	"strings"
-	tw "text/tabwriter"
 
 	bh "github.com/mainak55512/qwe/binaryhandler"
-	cp "github.com/mainak55512/qwe/compressor"
+	dl "github.com/mainak55512/qwe/delta"
+	"github.com/mainak55512/qwe/index"
+	ob "github.com/mainak55512/qwe/object"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 	res "github.com/mainak55512/qwe/reconstruct"
@@ -21,149 +22,174 @@ import (
 )
 
 // Tracks the difference of the uncommitted file
-func CommitUnit(filePath, message string) (string, int, error) {
+func CommitUnit(root, filePath, message string) (string, int, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return "", -3, err
+	}
 
 	// Get tracking details from _tracker.qwe
-	tracker, _, err := tr.GetTracker(0)
+	tracker, _, err := tr.GetTracker(root, 0)
 	if err != nil {
 		return "", -3, err
 	}
+	ix := index.Load(root)
 
-	// Create hash of file name, it will be used later to retrive file details from tracker
-	fileId := utl.Hasher(filePath)
+	fileObjectId, commitID, err := commitFile(root, filePath, message, tracker, ix)
+	if err != nil && !errors.Is(err, er.NoFileOrDiff) {
+		return fileObjectId, commitID, err
+	}
+	if err == nil {
+		if err = saveTracker(root, tracker); err != nil {
+			return "", -3, err // -3 means unsuccessful
+		}
+	}
 
-	// hash from file name and current time, will be used later as the file name of the commit
-	fileObjectId := utl.Hasher(fmt.Sprintf("%s%d", filePath, time.Now().UnixNano()))
+	// The index only speeds up later commits, failing to update it does not fail the commit
+	ix.Save(root)
+	return fileObjectId, commitID, err
+}
 
-	var commitID int
+// Saves the updated tracker in _tracker.qwe
+func saveTracker(root string, tracker tr.TrackerSchema) error {
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.CommitUnsuccessful
+	}
+	return tr.SaveTracker(root, 0, marshalContent)
+}
 
-	// Check if file is tracked
-	if val, ok := tracker[fileId]; ok {
-		if strings.HasPrefix(val.Base, "_bin_") {
-			fileObjectId, err = bh.CommitBinFile(filePath, val.Current)
-			if err != nil {
-				if errors.Is(err, er.NoFileOrDiff) {
-					for i := range val.Versions {
-						if val.Versions[i].UID == val.Current {
-							return "", i, err
-						}
-					}
-					return "", -2, err // this means asset is in base version
-				}
-				return "", -3, err
-			}
-		} else {
-			target := ".qwe/_object/" + fileObjectId
-
-			// This is the latest version of uncommitted file changes
-			new_file, err := os.Open(filePath)
-			if err != nil {
-				// return "", -3, err // -3 means unsuccessful
-				return val.Versions[len(val.Versions)-1].UID, len(val.Versions) - 1, er.NoFileOrDiff
-			}
-			defer new_file.Close()
+// Commits the file in the tracker on top of its checked out version, the new version becomes the head
+// of the checked out branch. filePath is canonical. Returns the version and commit number the file is at,
+// er.NoFileOrDiff if it is unchanged. Files the index knows to be unchanged are neither read nor reconstructed,
+// a file whose content hash matches the recorded hash of its version is not reconstructed.
+func commitFile(root, filePath, message string, tracker tr.TrackerSchema, ix index.Index) (string, int, error) {
 
-			// Reconstruct the file to the latest committed version
-			// by applying all the changes to the base version
-			if err = res.Reconstruct(val, target, -1); err != nil {
-				return "", -3, err // -3 means unsuccessful
-			}
+	// Create hash of file name, it will be used later to retrive file details from tracker
+	fileId := utl.Hasher(filePath)
 
-			current_file, err := os.Open(target)
-			if err != nil {
-				return "", -3, err
-			}
+	// Check if file is tracked
+	val, ok := tracker[fileId]
+	if !ok {
+		return "", -3, er.FileNotTracked // -3 means unsuccessful
+	}
+	workPath := utl.WorkPath(root, filePath)
 
-			current_scanner := bufio.NewScanner(current_file)
+	// Changes are compared against the checked out version and committed on top of it
+	head := val.Current
+	unchanged := func(hash string) (string, int, error) {
+		ix.Update(fileId, workPath, head, hash)
+		return head, val.CommitNumber(head), er.NoFileOrDiff
+	}
+	if _, ok := ix.Unchanged(fileId, workPath, head); ok {
+		return head, val.CommitNumber(head), er.NoFileOrDiff
+	}
 
-			new_scanner := bufio.NewScanner(new_file)
+	// hash from file name and current time, identifies the new version
+	fileObjectId := utl.Hasher(fmt.Sprintf("%s%d", filePath, time.Now().UnixNano()))
 
-			var diff_content string
+	// Object holding the content of the new version, the hash of that content and the keyframe if any
+	var objID, hash, snapshot, deltaOf string
+	var err error
 
-			// Find the difference between latest uncommitted and committed versions and store that in diff_content
-			// difference is stored as <line-number> @@@ <new string value>
-			line := 0
-			for new_scanner.Scan() {
-				line++
-				current_scanner.Scan()
-				if !bytes.Equal(current_scanner.Bytes(), new_scanner.Bytes()) {
-					diff_content += fmt.Sprintf("%d @@@ %s\n", line, utl.ConvStrEnc(new_scanner.Text()))
-				}
-			}
+	if strings.HasPrefix(val.Base, "_bin_") {
+		if hash, err = utl.FileContentID(workPath); err != nil {
+			return "", -3, err
+		}
+		if hash == val.ContentHash(head) {
+			return unchanged(hash)
+		}
+		objID, deltaOf, err = bh.CommitBinFile(root, workPath, val.Object(head), val.DeltaOf(head))
+		if errors.Is(err, er.NoFileOrDiff) {
+			return unchanged(hash)
+		}
+		if err != nil {
+			return "", -3, err
+		}
+	} else {
+		// This is the latest version of uncommitted file changes
+		new_content, err := os.ReadFile(workPath)
+		if err != nil {
+			return head, val.CommitNumber(head), er.NoFileOrDiff
+		}
+		hash = utl.ContentID(new_content)
+		if hash == val.ContentHash(head) {
+			return unchanged(hash)
+		}
 
-			// This ensures no redundent commits are created for the file if there is no change
-			if diff_content == "" {
-				if !current_scanner.Scan() {
-					os.Remove(target)
-					if strings.HasPrefix(val.Current, "_base_") && len(val.Versions) == 0 {
-						return val.Base, -2, er.NoFileOrDiff
-					}
-					return val.Versions[len(val.Versions)-1].UID, len(val.Versions) - 1, er.NoFileOrDiff
-				}
-			}
+		// Reconstruct the version the commit is made on top of
+		headNumber := val.CommitNumber(head)
+		current_lines, err := res.Lines(root, val, headNumber)
+		if err != nil {
+			return "", -3, err // -3 means unsuccessful
+		}
 
-			// Adding total line number of uncommitted file on top of the diff_content
-			// This line number will be used while reconstructing the file later
-			diff_content = fmt.Sprintf("%d\n%s", line, diff_content)
-			current_file.Close()
+		// Find the edit script that turns that version into the uncommitted one
+		edits := dl.Diff(current_lines, dl.SplitLines(new_content))
 
-			output_content, err := os.Create(target)
-			if err != nil {
-				return "", -3, err // -3 means unsuccessful
-			}
+		// This ensures no redundent commits are created for the file if there is no change
+		if dl.Unchanged(edits) {
+			return unchanged(hash)
+		}
 
-			output_writer := bufio.NewWriter(output_content)
-			_, err = output_writer.WriteString(diff_content)
-			if err != nil {
-				return "", -3, er.BaseWriteErr // -3 means unsuccessful
-			}
-			if err = output_writer.Flush(); err != nil {
-				return "", -3, er.OutputWriteErr // -3 means unsuccessful
-			}
-			output_content.Close()
+		// Write the compressed commit file
+		if objID, err = ob.Write(root, "", dl.Encode(edits)); err != nil {
+			return "", -3, er.OutputWriteErr // -3 means unsuccessful
+		}
 
-			// Compressing the commit file
-			if err = cp.CompressFile(target); err != nil {
-				return "", -3, err // -3 means unsuccessful
+		// Once reconstructing would replay too many deltas the full content is stored as well,
+		// reconstruction starts from the nearest one
+		meta, err := tr.GetMeta(root)
+		if err != nil {
+			return "", -3, err
+		}
+		_, chain, err := val.DeltaChain(headNumber)
+		if err != nil {
+			return "", -3, err
+		}
+		if tr.IsKeyframe(len(chain)+1, meta.Keyframes()) {
+			if snapshot, err = ob.Write(root, "_base_", new_content); err != nil {
+				return "", -3, er.OutputWriteErr
 			}
 		}
-
-		// Update tracker
-		val.Versions = append(val.Versions, tr.VersionDetails{
-			UID:           fileObjectId,
-			CommitMessage: message,
-			TimeStamp:     time.Now().String()[:16],
-		})
-		val.Current = fileObjectId
-		tracker[fileId] = val
-
-		commitID = len(val.Versions) - 1
-
-	} else {
-		return "", -3, er.FileNotTracked // -3 means unsuccessful
-	}
-
-	// Save the updated tracker in _tracker.qwe
-	marshalContent, err := json.MarshalIndent(tracker, "", " ")
-	if err != nil {
-		return "", -3, er.CommitUnsuccessful // -3 means unsuccessful
 	}
 
-	if err = tr.SaveTracker(0, marshalContent); err != nil {
-		return "", -3, err // -3 means unsuccessful
-	}
+	// Update tracker
+	val.Versions = append(val.Versions, tr.VersionDetails{
+		UID:           fileObjectId,
+		ObjID:         objID,
+		Parent:        head,
+		Hash:          hash,
+		Snapshot:      snapshot,
+		DeltaOf:       deltaOf,
+		CommitMessage: message,
+		TimeStamp:     time.Now().String()[:16],
+	})
+	val.Current = fileObjectId
+	val.AdvanceBranch(fileObjectId)
+	tracker[fileId] = val
+	ix.Update(fileId, workPath, fileObjectId, hash)
+
+	return fileObjectId, len(val.Versions) - 1, nil
+}
 
-	fmt.Println("Committed", filePath, " successfully with commit id", commitID)
-	return fileObjectId, commitID, nil
+// Files a commit added to or removed from a group because of its sources
+type GroupChanges struct {
+	Added   []string
+	Removed []string
 }
 
-// Commit all file changes that are tracked in the group
-func CommitGroup(groupName, commitMessage string) error {
+// Commit all file changes that are tracked in the group, returns the commit id of the group.
+// Groups with sources first pick up new files matching them and drop files that disappeared from disk.
+func CommitGroup(root, groupName, commitMessage string) (int, GroupChanges, error) {
+	var changes GroupChanges
 
 	// Get group tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(root, 1)
 	if err != nil {
-		return err
+		return -1, changes, err
 	}
 
 	groupID := utl.Hasher(groupName)
@@ -172,7 +198,7 @@ func CommitGroup(groupName, commitMessage string) error {
 	// Check if valid group
 	gr, ok := groupTracker[groupID]
 	if !ok {
-		return er.InvalidGroup
+		return -1, changes, er.InvalidGroup
 	}
 
 	// version order array maintains the order of commit history, appending new commit version here
@@ -181,90 +207,178 @@ func CommitGroup(groupName, commitMessage string) error {
 	// Fetching the current group commit
 	current, ok := gr.Versions[gr.Current]
 	if !ok {
-		return er.CurrentGrpErr
+		return -1, changes, er.CurrentGrpErr
+	}
+
+	// Files of the new commit
+	files := maps.Clone(current.Files)
+	if len(gr.Sources) > 0 {
+		if changes, err = syncSources(root, gr.Sources, files); err != nil {
+			return -1, changes, err
+		}
+	}
+
+	// The files are committed in memory, the trackers are saved once all of them are committed
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return -1, changes, err
 	}
+	ix := index.Load(root)
 
 	// newFiles contains the modified file details for the new commit
 	newFiles := make(map[string]tr.FileDetails)
+	committed := false
 
-	for k := range current.Files {
+	for k := range files {
 
 		// Commit each and every file that is tracked in the group
-		fileObjectID, commitID, err := CommitUnit(current.Files[k].FileName, commitMessage)
+		fileObjectID, commitID, err := commitFile(root, files[k].FileName, commitMessage, tracker, ix)
 
 		// Do not treat it as error if there is no change in the file
 		if err != nil && !errors.Is(err, er.NoFileOrDiff) {
-			return err
+			return -1, changes, err
 		}
+		committed = committed || err == nil
 
 		// Add modified file details to newFiles
 		newFiles[k] = tr.FileDetails{
-			FileName:     current.Files[k].FileName,
+			FileName:     files[k].FileName,
 			CommitNumber: commitID,
 			FileObjID:    fileObjectID,
 		}
 	}
 
-	// Update current version with the newly created commit in the group tracker
-	gr.Current = groupObjID
-
-	// Add new entry to the versions details of the group tracker
+	// Add new entry to the versions details of the group tracker, on top of the current commit
 	gr.Versions[groupObjID] = tr.GroupVersionDetails{
+		Parent:        gr.Current,
 		CommitMessage: commitMessage,
 		Files:         newFiles,
 	}
 
+	// Update current version with the newly created commit in the group tracker
+	gr.Current = groupObjID
+	gr.AdvanceBranch(groupObjID)
+
 	commitID := len(gr.Versions) - 1
 
 	// Update the group tracker with new details
 	groupTracker[groupID] = gr
 
+	// The file tracker goes first as the group refers to its versions
+	if committed {
+		if err = saveTracker(root, tracker); err != nil {
+			return -1, changes, err
+		}
+	}
+	ix.Save(root)
+
 	marshalContent, err := json.MarshalIndent(groupTracker, "", " ")
 	if err != nil {
-		return er.CommitUnsuccessful
+		return -1, changes, er.CommitUnsuccessful
 	}
 
 	// Update the tracker
-	if err = tr.SaveTracker(1, marshalContent); err != nil {
-		return err
+	if err = tr.SaveTracker(root, 1, marshalContent); err != nil {
+		return -1, changes, err
+	}
+	return commitID, changes, nil
+}
+
+// Adds the files matching the sources of a group that are not among its files yet, tracking them if needed,
+// and removes the files that disappeared from disk
+func syncSources(root string, sources []tr.GroupSource, files map[string]tr.FileDetails) (GroupChanges, error) {
+	var changes GroupChanges
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return changes, err
+	}
+
+	var untracked []string
+	for _, source := range sources {
+		matches, err := source.Files(root)
+		if err != nil {
+			return changes, err
+		}
+		for _, filePath := range matches {
+			fileId := utl.Hasher(filePath)
+			if _, ok := files[fileId]; ok {
+				continue
+			}
+			if _, ok := tracker[fileId]; !ok {
+				untracked = append(untracked, filePath)
+			}
+			files[fileId] = tr.FileDetails{FileName: filePath}
+			changes.Added = append(changes.Added, filePath)
+		}
+	}
+	if len(untracked) > 0 {
+		if _, err := tr.StartTrackingPaths(root, untracked, false); err != nil {
+			return changes, err
+		}
 	}
-	fmt.Println("Successfully committed to group", groupName, "with commit id", commitID)
-	return nil
+
+	for fileId, file := range files {
+		if !utl.FileExists(utl.WorkPath(root, file.FileName)) {
+			delete(files, fileId)
+			changes.Removed = append(changes.Removed, file.FileName)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	return changes, nil
 }
 
-// Prints the commit history with CommitID, Commit message, and time stamp details
-func GetCommitList(filePath string) error {
+// Returns the commit history of the file, the index of a version is its commit id
+func GetCommitList(root, filePath string) ([]tr.VersionDetails, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get tracker details
-	tracker, _, err := tr.GetTracker(0)
+	tracker, _, err := tr.GetTracker(root, 0)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fileId := utl.Hasher(filePath)
+	val, ok := tracker[utl.Hasher(filePath)]
+	if !ok {
+		return nil, er.FileNotTracked
+	}
+	return val.Versions, nil
+}
 
-	w := new(tw.Writer)
-	w.Init(os.Stdout, 0, 0, 0, ' ', tw.TabIndent)
+// Returns the moves of the file in the order they were made
+func GetRenameList(root, filePath string) ([]tr.RenameDetails, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return nil, err
+	}
 
-	// Loop through versions of the file and print commitID, commit message and time stamp for each entry
-	for i, e := range tracker[fileId].Versions {
-		fmt.Fprintln(w,
-			fmt.Sprintf(
-				"\nID:\t%d\nCommit Message:\t%s\nTime Stamp:\t%s\n", i, e.CommitMessage, e.TimeStamp,
-			),
-		)
-		w.Flush()
+	// Get tracker details
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	val, ok := tracker[utl.Hasher(filePath)]
+	if !ok {
+		return nil, er.FileNotTracked
+	}
+	return val.Renames, nil
 }
 
-// Shows list of all commits of the specified group
-func GetGroupCommitList(groupName string) error {
+// Returns the list of all commits of the specified group, the index of a version is its commit id
+func GetGroupCommitList(root, groupName string) ([]tr.GroupVersionDetails, error) {
 
 	// Get group tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(root, 1)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	groupID := utl.Hasher(groupName)
@@ -272,69 +386,65 @@ func GetGroupCommitList(groupName string) error {
 	// Check if valid group
 	gr, ok := groupTracker[groupID]
 	if !ok {
-		return er.InvalidGroup
+		return nil, er.InvalidGroup
 	}
 
-	w := new(tw.Writer)
-	w.Init(os.Stdout, 0, 0, 0, ' ', tw.TabIndent)
-
-	// Print every version details
-	i := 0
+	// Collect every version details in commit order
+	versions := make([]tr.GroupVersionDetails, 0, len(gr.VersionOrder))
 	for _, k := range gr.VersionOrder {
-		fmt.Fprintln(w, fmt.Sprintf("\nID:\t%d\nCommit Message:\t%s\n", i, gr.Versions[k].CommitMessage))
-		i++
+		versions = append(versions, gr.Versions[k])
 	}
-	w.Flush()
-	return nil
+	return versions, nil
 }
 
-// Shows the current checked out version of the file
-func CurrentCommit(filePath string) error {
+// Returns the commit id and details of the current checked out version of the file,
+// commit id -2 means the base version is checked out
+func CurrentCommit(root, filePath string) (int, tr.VersionDetails, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return -1, tr.VersionDetails{}, err
+	}
 
 	// Get tracker details
-	tracker, _, err := tr.GetTracker(0)
+	tracker, _, err := tr.GetTracker(root, 0)
 	if err != nil {
-		return err
+		return -1, tr.VersionDetails{}, err
 	}
 	fileId := utl.Hasher(filePath)
 
 	// Return error if the file is not tracked
 	val, ok := tracker[fileId]
 	if !ok {
-		return er.FileNotTracked
+		return -1, tr.VersionDetails{}, er.FileNotTracked
 	}
 
 	// Get the current version of the file
 	currentVersion := val.Current
 
-	w := new(tw.Writer)
-	w.Init(os.Stdout, 0, 0, 0, ' ', tw.TabIndent)
-
-	// If the current checked out version is a base file, the print the base details
+	// If the current checked out version is a base file, then return the base details
 	// if strings.HasPrefix(currentVersion, "_base_") {
 	if currentVersion == val.Base {
-		fmt.Fprintf(w, "\nCurrent Commit ID:\tbase\nCommit Message:\tBase version\n")
-	} else {
+		return -2, tr.VersionDetails{UID: val.Base, CommitMessage: "Base version"}, nil
+	}
 
-		// Loop through the file versions, when current version is found print the details of commitID, commit message
-		for i, e := range tracker[fileId].Versions {
-			if e.UID == currentVersion {
-				fmt.Fprintf(w, "\nCurrent Commit ID:\t%d\nCommit Message:\t%s\n", i, e.CommitMessage)
-				break
-			}
+	// Loop through the file versions, when current version is found return its commitID and details
+	for i, e := range val.Versions {
+		if e.UID == currentVersion {
+			return i, e, nil
 		}
 	}
-	w.Flush()
-	return nil
+	return -1, tr.VersionDetails{}, er.InvalidCommitNo
 }
 
-// Prints current group commit details
-func GroupCommitDetails(groupName string, commitNumber int) error {
+// Returns the commit id and details of a group commit, commitNumber -1 refers to the current commit
+func GroupCommitDetails(root, groupName string, commitNumber int) (int, tr.GroupVersionDetails, error) {
 
 	// Get group tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(root, 1)
 	if err != nil {
-		return err
+		return -1, tr.GroupVersionDetails{}, err
 	}
 
 	groupID := utl.Hasher(groupName)
@@ -342,7 +452,7 @@ func GroupCommitDetails(groupName string, commitNumber int) error {
 	// Check if valid group
 	val, ok := groupTracker[groupID]
 	if !ok {
-		return er.InvalidGroup
+		return -1, tr.GroupVersionDetails{}, er.InvalidGroup
 	}
 
 	// Get the commit id of specific version from the group tracker
@@ -354,8 +464,8 @@ func GroupCommitDetails(groupName string, commitNumber int) error {
 	if commitNumber == -1 {
 		commit = val.Current
 	} else {
-		if commitNumber > len(val.VersionOrder)-1 {
-			return er.InvalidCommitNo
+		if commitNumber < 0 || commitNumber > len(val.VersionOrder)-1 {
+			return -1, tr.GroupVersionDetails{}, er.InvalidCommitNo
 		}
 		commit = val.VersionOrder[commitNumber]
 	}
@@ -366,57 +476,46 @@ func GroupCommitDetails(groupName string, commitNumber int) error {
 			break
 		}
 	}
-
-	// Print current commit details
-	w := new(tw.Writer)
-	w.Init(os.Stdout, 0, 0, 0, ' ', tw.TabIndent)
-	fmt.Fprintf(w, "\nName:\t %s\nCurrent Commit ID:\t %d\nCommit Message:\t %s\n", val.GroupName, commitID, val.Versions[commit].CommitMessage)
-	files := val.Versions[commit].Files
-	fmt.Fprintf(w, "\nAssociated files:\n")
-	for e := range files {
-		fmt.Fprintf(w, "File: %s, \tCommitID: %d\n", files[e].FileName, files[e].CommitNumber)
-	}
-	w.Flush()
-	return nil
+	return commitID, val.Versions[commit], nil
 }
 
-// Shows the names of groups tracked in the current repository
-func GroupNameList(filePath string) error {
+// Returns the names of groups tracked in the repository,
+// if filePath is not empty only the groups in which the file is tracked are returned
+func GroupNameList(root, filePath string) ([]string, error) {
 	// Get group tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(root, 1)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	groupNames := []string{}
 	if filePath == "" {
-		// print the group names
+		// collect the group names
 		for k := range groupTracker {
-			fmt.Println(groupTracker[k].GroupName)
+			groupNames = append(groupNames, groupTracker[k].GroupName)
 		}
 	} else {
 
-		tracker, _, err := tr.GetTracker(0)
+		// Identify the file by its canonical path
+		if filePath, err = utl.Canonical(root, filePath); err != nil {
+			return nil, err
+		}
+
+		tracker, _, err := tr.GetTracker(root, 0)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fileID := utl.Hasher(filePath)
 		_, ok := tracker[fileID]
 		if !ok {
-			return er.FileNotTracked
+			return nil, er.FileNotTracked
 		}
-		var isTracked bool = false
 		for k := range groupTracker {
-			for l := range groupTracker[k].Versions[groupTracker[k].Current].Files {
-				if l == fileID {
-					fmt.Println(groupTracker[k].GroupName)
-					isTracked = true
-					break
-				}
+			if _, ok := groupTracker[k].Versions[groupTracker[k].Current].Files[fileID]; ok {
+				groupNames = append(groupNames, groupTracker[k].GroupName)
 			}
 		}
-		if !isTracked {
-			fmt.Println("File is not associated with any group!")
-		}
 	}
-	return nil
+	sort.Strings(groupNames)
+	return groupNames, nil
 }
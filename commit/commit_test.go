@@ -0,0 +1,174 @@
+package commit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mainak55512/qwe/fsck"
+	"github.com/mainak55512/qwe/index"
+	in "github.com/mainak55512/qwe/initializer"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	rb "github.com/mainak55512/qwe/rebase"
+	res "github.com/mainak55512/qwe/reconstruct"
+	rv "github.com/mainak55512/qwe/revert"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestCommitUnit_RevertCycles tests that commits made after reverting build on the reverted version
+// and that every version still reconstructs once the history branched
+func TestCommitUnit_RevertCycles(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+
+	// Keyframes every other version exercise reconstruction from keyframes of other branches
+	if err := tr.SaveMeta(root, tr.Meta{SchemaVersion: tr.SchemaVersion, KeyframeInterval: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string // content of every commit
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(content string) int {
+		t.Helper()
+		write(content)
+		_, commitID, err := CommitUnit(root, "notes.txt", content)
+		if err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+		want = append(want, content)
+		return commitID
+	}
+	revert := func(commitNumber int, content string) {
+		t.Helper()
+		if _, err := rv.Revert(root, commitNumber, "notes.txt"); err != nil {
+			t.Fatalf("Revert(%d) failed: %v", commitNumber, err)
+		}
+		if got, _ := os.ReadFile(notes); string(got) != content {
+			t.Errorf("Revert(%d): file content = %q; want %q", commitNumber, got, content)
+		}
+	}
+	parent := func(commitID int) int {
+		t.Helper()
+		tracker, _, err := tr.GetTracker(root, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tracker[utl.Hasher("notes.txt")].Parent(commitID)
+	}
+
+	write("a\nb\nc\n")
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	commit("a\nb\nc\nd\n")
+	commit("a\nb\nc\nd\ne\n")
+	commit("a\nb\nc\nd\ne\nf\n")
+
+	// The file equals the reverted version, nothing is committed
+	revert(1, want[1])
+	if _, commitID, err := CommitUnit(root, "notes.txt", "unchanged"); !errors.Is(err, er.NoFileOrDiff) || commitID != 1 {
+		t.Errorf("CommitUnit() = %d, %v; want 1, NoFileOrDiff", commitID, err)
+	}
+
+	// Edits of the reverted version are committed on top of it and become the head
+	revert(0, want[0])
+	if commitID := commit("A\nb\nc\nd\n"); commitID != 3 || parent(commitID) != 0 {
+		t.Errorf("commit after revert = %d with parent %d; want 3 with parent 0", commitID, parent(commitID))
+	}
+	revert(-1, want[3])
+	if commitID := commit("A\nb\nc\nd\nx\n"); parent(commitID) != 3 {
+		t.Errorf("commit %d has parent %d; want 3", commitID, parent(commitID))
+	}
+
+	// Back on the old line, a commit there forks again
+	revert(2, want[2])
+	if commitID := commit("a\nb\nc\nd\ne\nf\ng\n"); parent(commitID) != 2 {
+		t.Errorf("commit %d has parent %d; want 2", commitID, parent(commitID))
+	}
+	revert(-1, want[len(want)-1])
+
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val := tracker[utl.Hasher("notes.txt")]
+	for i, content := range want {
+		lines, err := res.Lines(root, val, i)
+		if err != nil {
+			t.Fatalf("Lines(%d) failed: %v", i, err)
+		}
+		if got := strings.Join(lines, ""); got != content {
+			t.Errorf("version %d: expected %q, got %q", i, content, got)
+		}
+	}
+	report, err := fsck.Check(root)
+	if err != nil || !report.Healthy() {
+		t.Errorf("Check() = %+v, %v; want a healthy repository", report.Problems, err)
+	}
+}
+
+// TestRevert_Index tests that reverting and rebasing a file leave no index entry of its previous content
+func TestRevert_Index(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	fileId := utl.Hasher("notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+
+	// Files modified long enough ago are recorded in the index on commit
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		past := time.Now().Add(-time.Minute)
+		if err := os.Chtimes(notes, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v0\n")
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	for _, content := range []string{"v1\n", "v2\n"} {
+		write(content)
+		if _, _, err := CommitUnit(root, "notes.txt", content); err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+	}
+	expect := func(step string) {
+		t.Helper()
+		tracker, _, err := tr.GetTracker(root, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry, ok := index.Load(root)[fileId]; ok && entry.Version != tracker[fileId].Current {
+			t.Errorf("after %s the index entry is of version %s, the file is at %s", step, entry.Version, tracker[fileId].Current)
+		}
+	}
+	if entry, ok := index.Load(root)[fileId]; !ok || entry.Version == "" {
+		t.Fatalf("commit did not record the file in the index")
+	}
+
+	if _, err := rv.Revert(root, 0, "notes.txt"); err != nil {
+		t.Fatalf("Revert() failed: %v", err)
+	}
+	expect("revert")
+	if err := rb.Rebase(root, "notes.txt"); err != nil {
+		t.Fatalf("Rebase() failed: %v", err)
+	}
+	expect("rebase")
+}
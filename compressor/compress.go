@@ -5,75 +5,141 @@ import (
 	"compress/zlib"
 	"errors"
 	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
 	"io"
 	"os"
 )
 
-// Compresses the file with zlib
-func CompressFile(filePath string) error {
-	var buf bytes.Buffer
-	file, err := os.Open(filePath)
-	if err != nil {
-		return er.CompOpenErr
+// Earlier versions of qwe flushed their zlib streams without closing them, such a stream ends with
+// the marker of a sync flush instead of the checksum
+var syncFlushMarker = []byte{0x00, 0x00, 0xff, 0xff}
+
+// Remembers the last bytes read from the compressed stream
+type tailReader struct {
+	r    io.Reader
+	tail []byte
+}
+
+func (t *tailReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.tail = append(t.tail, p[:n]...)
+	if len(t.tail) > len(syncFlushMarker) {
+		t.tail = append([]byte(nil), t.tail[len(t.tail)-len(syncFlushMarker):]...)
 	}
-	defer file.Close()
-	zw, err := zlib.NewWriterLevel(&buf, zlib.BestCompression)
-	if err != nil {
-		return er.CompBufInitErr
+	return n, err
+}
+
+// Decompressing reader over zlib compressed content
+type reader struct {
+	zr  io.ReadCloser
+	src *tailReader
+}
+
+// Streams of earlier versions of qwe end unexpectedly right after a sync flush and are complete,
+// any other stream ending early is truncated and reported as such
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.zr.Read(p)
+	if errors.Is(err, io.ErrUnexpectedEOF) && bytes.Equal(r.src.tail, syncFlushMarker) {
+		err = io.EOF
 	}
-	defer zw.Close()
+	return n, err
+}
 
-	// Copy file content to the buffer as well as compressing it
-	if _, err = io.Copy(zw, file); err != nil {
-		return er.BufCopyErr
+func (r *reader) Close() error {
+	return r.zr.Close()
+}
+
+// Returns a reader that decompresses the zlib compressed content read from r
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	src := &tailReader{r: r}
+	zr, err := zlib.NewReader(src)
+	if err != nil {
+		return nil, er.DecompBufInitErr
 	}
-	zw.Flush()
-	com_file, err := os.Create(filePath)
+	return &reader{zr: zr, src: src}, nil
+}
+
+// Returns a writer that compresses everything written to it into w, it must be closed to complete the stream
+func NewWriter(w io.Writer) (io.WriteCloser, error) {
+	zw, err := zlib.NewWriterLevel(w, zlib.BestCompression)
 	if err != nil {
-		return err
+		return nil, er.CompBufInitErr
 	}
-	defer com_file.Close()
+	return zw, nil
+}
 
-	// Copy compressed content from buffer to the file
-	if _, err = io.Copy(com_file, &buf); err != nil {
-		return er.BufCopyErr
+// Returns the zlib compressed form of data
+func Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := NewWriter(&buf)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if _, err = zw.Write(data); err != nil {
+		return nil, er.BufCopyErr
+	}
+	if err = zw.Close(); err != nil {
+		return nil, er.BufCopyErr
+	}
+	return buf.Bytes(), nil
 }
 
-// decompresses the file using a temporary one
-func DecompressFile(filePath string) error {
-	input, err := os.Open(filePath)
+// Returns the decompressed form of zlib compressed data
+func Decompress(data []byte) ([]byte, error) {
+	zr, err := NewReader(bytes.NewReader(data))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer input.Close()
+	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, er.BufCopyErr
+	}
+	return content, nil
+}
 
-	// Create zlib reader for the file
-	zr, err := zlib.NewReader(input)
+// Reads a compressed file and returns its decompressed content, the file itself is left untouched
+func ReadFile(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return er.DecompBufInitErr
+		return nil, err
+	}
+	defer file.Close()
+	zr, err := NewReader(file)
+	if err != nil {
+		return nil, err
 	}
 	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, er.BufCopyErr
+	}
+	return content, nil
+}
 
-	tmpPath := filePath + ".tmp"
-	output, err := os.Create(tmpPath)
+// Compresses data into the file, replacing it atomically
+func WriteFile(filePath string, data []byte) error {
+	compressed, err := Compress(data)
 	if err != nil {
 		return err
 	}
+	return utl.WriteFileAtomic(filePath, compressed, 0644)
+}
 
-	defer output.Close()
-
-	// Decompress and copy content from zlib reader to temporary file
-	if _, err = io.Copy(output, zr); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
-		os.Remove(tmpPath)
-		return er.BufCopyErr
+// Decompresses the file into dst
+func CopyTo(dst io.Writer, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
 	}
-
-	// Rename temporary file with the actual output file name
-	if err = os.Rename(tmpPath, filePath); err != nil {
+	defer file.Close()
+	zr, err := NewReader(file)
+	if err != nil {
 		return err
 	}
-
+	defer zr.Close()
+	if _, err = io.Copy(dst, zr); err != nil {
+		return er.BufCopyErr
+	}
 	return nil
 }
@@ -0,0 +1,46 @@
+package compressor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"testing"
+)
+
+// TestDecompress_Unterminated tests that streams flushed but never closed, as written by earlier versions of qwe, are read
+func TestDecompress_Unterminated(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte("{}"))
+	zw.Flush()
+
+	content, err := Decompress(buf.Bytes())
+	if err != nil || string(content) != "{}" {
+		t.Errorf("Decompress() = %q, %v; want \"{}\", nil", content, err)
+	}
+}
+
+// TestDecompress_Truncated tests that a stream cut short is reported rather than read as complete
+func TestDecompress_Truncated(t *testing.T) {
+	compressed, err := Compress(bytes.Repeat([]byte("qwe tracks files\n"), 100))
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	for _, cut := range []int{1, 4, len(compressed) / 2} {
+		if _, err := Decompress(compressed[:len(compressed)-cut]); err == nil {
+			t.Errorf("Decompress() of a stream missing %d bytes succeeded", cut)
+		}
+	}
+}
+
+// TestCompress_RoundTrip tests that compressed data decompresses to the original
+func TestCompress_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("qwe tracks files\n"), 100)
+	compressed, err := Compress(data)
+	if err != nil {
+		t.Fatalf("Compress() failed: %v", err)
+	}
+	content, err := Decompress(compressed)
+	if err != nil || !bytes.Equal(content, data) {
+		t.Errorf("round trip failed: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package delta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	er "github.com/mainak55512/qwe/qwerror"
+)
+
+// First line of every binary delta object, followed by the size of the target and the instructions
+const BinaryMarker = "qwe-bdelta 1"
+
+const (
+	opCopy   byte = 'c' // copy a range of the source
+	opInsert byte = 'i' // insert bytes stored in the delta
+)
+
+// Length of the source blocks matched in the target, shorter matches are inserted
+const blockSize = 32
+
+// Base of the rolling hash over a block
+const hashBase = 257
+
+// Source offsets kept per block hash, bounds the work on highly repetitive content
+const maxCandidates = 8
+
+// Returns copy and insert instructions that turn source into target.
+// Blocks of the source are found in the target with a rolling hash and extended as far as they match.
+func DiffBinary(source, target []byte) []byte {
+	var out bytes.Buffer
+	out.WriteString(BinaryMarker + "\n")
+	writeUvarint(&out, uint64(len(target)))
+
+	// Hashes of the source blocks at block aligned offsets
+	blocks := make(map[uint32][]int)
+	for off := 0; off+blockSize <= len(source); off += blockSize {
+		h := hashBlock(source[off : off+blockSize])
+		if len(blocks[h]) < maxCandidates {
+			blocks[h] = append(blocks[h], off)
+		}
+	}
+
+	// Weight of the byte leaving the block when the hash rolls
+	var outWeight uint32 = 1
+	for i := 1; i < blockSize; i++ {
+		outWeight *= hashBase
+	}
+
+	pending := 0 // start of the bytes not yet covered by an instruction
+	pos := 0
+	var h uint32
+	hashed := false
+	for pos+blockSize <= len(target) {
+		if !hashed {
+			h = hashBlock(target[pos : pos+blockSize])
+			hashed = true
+		}
+
+		matchOff, matchLen := -1, 0
+		for _, off := range blocks[h] {
+			n := commonPrefix(source[off:], target[pos:])
+			if n >= blockSize && n > matchLen {
+				matchOff, matchLen = off, n
+			}
+		}
+		if matchOff < 0 {
+			if pos+blockSize < len(target) {
+				h = (h-uint32(target[pos])*outWeight)*hashBase + uint32(target[pos+blockSize])
+			}
+			pos++
+			continue
+		}
+
+		// Extend the match backwards over bytes that would be inserted otherwise
+		start := pos
+		for matchOff > 0 && start > pending && source[matchOff-1] == target[start-1] {
+			matchOff--
+			start--
+			matchLen++
+		}
+		writeInsert(&out, target[pending:start])
+		out.WriteByte(opCopy)
+		writeUvarint(&out, uint64(matchOff))
+		writeUvarint(&out, uint64(matchLen))
+
+		pos = start + matchLen
+		pending = pos
+		hashed = false
+	}
+	writeInsert(&out, target[pending:])
+	return out.Bytes()
+}
+
+// Applies a binary delta on the source and returns the target
+func ApplyBinary(source, delta []byte) ([]byte, error) {
+	if !bytes.HasPrefix(delta, []byte(BinaryMarker+"\n")) {
+		return nil, er.InvalidDelta
+	}
+	reader := bytes.NewReader(delta[len(BinaryMarker)+1:])
+	size, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, er.InvalidDelta
+	}
+
+	// The size is only trusted as far as the delta and the source can produce it
+	target := make([]byte, 0, min(size, uint64(len(source))+uint64(len(delta))))
+	for {
+		op, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		switch op {
+		case opCopy:
+			off, err1 := binary.ReadUvarint(reader)
+			n, err2 := binary.ReadUvarint(reader)
+			if err1 != nil || err2 != nil || off > uint64(len(source)) || n > uint64(len(source))-off {
+				return nil, er.InvalidDelta
+			}
+			target = append(target, source[off:off+n]...)
+		case opInsert:
+			n, err := binary.ReadUvarint(reader)
+			if err != nil || n > uint64(reader.Len()) {
+				return nil, er.InvalidDelta
+			}
+			start := len(target)
+			target = append(target, make([]byte, n)...)
+			if _, err := io.ReadFull(reader, target[start:]); err != nil {
+				return nil, er.InvalidDelta
+			}
+		default:
+			return nil, er.InvalidDelta
+		}
+	}
+	if uint64(len(target)) != size {
+		return nil, er.InvalidDelta
+	}
+	return target, nil
+}
+
+func hashBlock(block []byte) uint32 {
+	var h uint32
+	for _, b := range block {
+		h = h*hashBase + uint32(b)
+	}
+	return h
+}
+
+// Returns the length of the common prefix of a and b
+func commonPrefix(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+func writeInsert(out *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	out.WriteByte(opInsert)
+	writeUvarint(out, uint64(len(data)))
+	out.Write(data)
+}
+
+func writeUvarint(out *bytes.Buffer, value uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	out.Write(buf[:binary.PutUvarint(buf[:], value)])
+}
@@ -0,0 +1,68 @@
+package delta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// TestBinaryDelta tests that binary deltas restore the target and stay small for small changes
+func TestBinaryDelta(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	source := make([]byte, 256*1024)
+	rng.Read(source)
+
+	edited := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	tests := []struct {
+		name     string
+		target   []byte
+		maxDelta int
+	}{
+		{"unchanged", source, 64},
+		{"insert", edited(source[:1000], []byte("inserted bytes"), source[1000:]), 128},
+		{"delete", edited(source[:5000], source[9000:]), 128},
+		{"replace", edited(source[:70000], bytes.Repeat([]byte{7}, 100), source[70100:]), 256},
+		{"append", edited(source, []byte("tail")), 64},
+		{"moved", edited(source[128*1024:], source[:128*1024]), 128},
+		{"empty", nil, 16},
+		{"unrelated", bytes.Repeat([]byte("abc"), 1000), 3100},
+	}
+	for _, tt := range tests {
+		delta := DiffBinary(source, tt.target)
+		if len(delta) > tt.maxDelta {
+			t.Errorf("%s: delta of %d bytes, want at most %d", tt.name, len(delta), tt.maxDelta)
+		}
+		got, err := ApplyBinary(source, delta)
+		if err != nil {
+			t.Fatalf("%s: ApplyBinary() failed: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, tt.target) {
+			t.Errorf("%s: ApplyBinary() did not restore the target", tt.name)
+		}
+	}
+
+	// An empty source only allows inserts
+	if got, err := ApplyBinary(nil, DiffBinary(nil, []byte("new"))); err != nil || string(got) != "new" {
+		t.Errorf("ApplyBinary() = %q, %v; want new", got, err)
+	}
+}
+
+// TestBinaryDelta_Invalid tests that damaged deltas are rejected
+func TestBinaryDelta_Invalid(t *testing.T) {
+	source := bytes.Repeat([]byte("0123456789abcdef"), 100)
+	delta := DiffBinary(source, append(source[:800:800], []byte("changed")...))
+	for name, damaged := range map[string][]byte{
+		"no marker": delta[len(BinaryMarker)+1:],
+		"truncated": delta[:len(delta)-3],
+		"bad op":    append(append([]byte{}, delta...), 'x'),
+	} {
+		if _, err := ApplyBinary(source, damaged); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := ApplyBinary(source[:100], delta); err == nil {
+		t.Errorf("expected an error for a copy beyond the source")
+	}
+}
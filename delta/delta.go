@@ -0,0 +1,188 @@
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// First line of every delta object written in the edit script format.
+// Objects without this marker are legacy '<line> @@@ <content>' deltas.
+const Marker = "qwe-delta 2"
+
+type Op byte
+
+const (
+	Keep   Op = '='
+	Delete Op = '-'
+	Insert Op = '+'
+)
+
+// Single step of an edit script, Line holds the line that is kept, deleted or inserted
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// Splits content into lines, every line keeps its terminating newline
+// except the last one if the content does not end with a newline
+func SplitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		idx := bytes.IndexByte(content, '\n')
+		if idx == -1 {
+			lines = append(lines, string(content))
+			break
+		}
+		lines = append(lines, string(content[:idx+1]))
+		content = content[idx+1:]
+	}
+	return lines
+}
+
+// Returns true if the edit script keeps every line as it is
+func Unchanged(edits []Edit) bool {
+	for _, e := range edits {
+		if e.Op != Keep {
+			return false
+		}
+	}
+	return true
+}
+
+// Serializes the edit script as a delta object
+//
+// Format:
+//
+//	qwe-delta 2
+//	= <number of lines kept>
+//	- <number of lines deleted>
+//	+ <base64 encoded inserted line>
+//
+// Lines remaining after the last record are kept as they are.
+func Encode(edits []Edit) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(Marker + "\n")
+
+	// Trailing kept lines are implicit
+	end := len(edits)
+	for end > 0 && edits[end-1].Op == Keep {
+		end--
+	}
+
+	for i := 0; i < end; {
+		op := edits[i].Op
+		if op == Insert {
+			fmt.Fprintf(&buf, "+ %s\n", utl.ConvStrEnc(edits[i].Line))
+			i++
+			continue
+		}
+
+		// Keep and delete records are run-length encoded
+		j := i
+		for j < end && edits[j].Op == op {
+			j++
+		}
+		fmt.Fprintf(&buf, "%c %d\n", op, j-i)
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// Applies a delta object on the lines of the previous version and returns the lines of the new version
+func Apply(lines []string, delta []byte) ([]string, error) {
+	if bytes.HasPrefix(delta, []byte(Marker+"\n")) {
+		return applyEditScript(lines, delta[len(Marker)+1:])
+	}
+	return applyLegacy(lines, delta)
+}
+
+func applyEditScript(lines []string, delta []byte) ([]string, error) {
+	output := make([]string, 0, len(lines))
+	pos := 0
+	scanner := bufio.NewScanner(bytes.NewReader(delta))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(delta)+1)
+	for scanner.Scan() {
+		record := scanner.Text()
+		if len(record) < 2 || record[1] != ' ' {
+			return nil, er.InvalidDelta
+		}
+		value := record[2:]
+		switch Op(record[0]) {
+		case Insert:
+			line, err := utl.ConvStrDec(value)
+			if err != nil {
+				return nil, er.InvalidDelta
+			}
+			output = append(output, line)
+		case Keep, Delete:
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 || pos+count > len(lines) {
+				return nil, er.InvalidDelta
+			}
+			if Op(record[0]) == Keep {
+				output = append(output, lines[pos:pos+count]...)
+			}
+			pos += count
+		default:
+			return nil, er.InvalidDelta
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, er.InvalidDelta
+	}
+
+	// Remaining lines are kept implicitly
+	return append(output, lines[pos:]...), nil
+}
+
+// Applies the delta format used before the edit script was introduced,
+// it stores the total number of lines followed by '<line-number> @@@ <base64 content>'
+// for every line that differs from the previous version at the same position
+func applyLegacy(lines []string, delta []byte) ([]string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(delta))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(delta)+1)
+	if !scanner.Scan() {
+		return nil, er.InvalidDelta
+	}
+	totalLines, err := strconv.Atoi(scanner.Text())
+	if err != nil {
+		return nil, er.InvalidDelta
+	}
+
+	changes := make(map[int]string)
+	for scanner.Scan() {
+		comp := strings.SplitN(scanner.Text(), " @@@ ", 2)
+		if len(comp) != 2 {
+			continue
+		}
+		lineNumber, err := strconv.Atoi(comp[0])
+		if err != nil {
+			return nil, er.InvalidDelta
+		}
+		decStr, err := utl.ConvStrDec(comp[1])
+		if err != nil {
+			return nil, err
+		}
+		changes[lineNumber] = decStr
+	}
+
+	// Legacy deltas were produced from line scanners, hence every line is
+	// terminated with a newline and carriage returns are dropped
+	output := make([]string, 0, totalLines)
+	for i := 0; i < totalLines; i++ {
+		if line, ok := changes[i+1]; ok {
+			output = append(output, line+"\n")
+		} else if i < len(lines) {
+			output = append(output, strings.TrimSuffix(strings.TrimSuffix(lines[i], "\n"), "\r")+"\n")
+		} else {
+			output = append(output, "\n")
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,127 @@
+package delta
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// lcsLength computes the length of the longest common subsequence with dynamic programming
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func randomLines(r *rand.Rand, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d\n", r.Intn(4))
+	}
+	return lines
+}
+
+// TestDiff_ShortestEditScript verifies that the edit script is valid and minimal for random inputs
+func TestDiff_ShortestEditScript(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 500; iter++ {
+		a := randomLines(r, r.Intn(20))
+		b := randomLines(r, r.Intn(20))
+		edits := Diff(a, b)
+
+		var gotA, gotB []string
+		kept := 0
+		for _, e := range edits {
+			switch e.Op {
+			case Keep:
+				gotA = append(gotA, e.Line)
+				gotB = append(gotB, e.Line)
+				kept++
+			case Delete:
+				gotA = append(gotA, e.Line)
+			case Insert:
+				gotB = append(gotB, e.Line)
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("edit script does not reproduce inputs\na: %q\nb: %q\nedits: %v", a, b, edits)
+		}
+		if want := lcsLength(a, b); kept != want {
+			t.Fatalf("edit script is not minimal: kept %d lines, want %d\na: %q\nb: %q", kept, want, a, b)
+		}
+	}
+}
+
+// TestEncodeApply_RoundTrip verifies that applying an encoded delta reproduces the new version
+func TestEncodeApply_RoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for iter := 0; iter < 200; iter++ {
+		a := randomLines(r, r.Intn(30))
+		b := randomLines(r, r.Intn(30))
+		if r.Intn(2) == 0 && len(b) > 0 {
+			b[len(b)-1] = strings.TrimSuffix(b[len(b)-1], "\n")
+		}
+
+		got, err := Apply(a, Encode(Diff(a, b)))
+		if err != nil {
+			t.Fatalf("Apply() failed: %v", err)
+		}
+		if strings.Join(got, "") != strings.Join(b, "") {
+			t.Fatalf("round trip mismatch\nwant: %q\ngot:  %q", b, got)
+		}
+	}
+}
+
+// TestEncode_SingleInsertion verifies that inserting one line at the top costs a single record
+func TestEncode_SingleInsertion(t *testing.T) {
+	var a []string
+	for i := 0; i < 5000; i++ {
+		a = append(a, fmt.Sprintf("key%d = %d\n", i, i))
+	}
+	b := append([]string{"# header\n"}, a...)
+
+	records := strings.Split(strings.TrimSuffix(string(Encode(Diff(a, b))), "\n"), "\n")
+	if len(records) != 2 || records[0] != Marker {
+		t.Fatalf("expected marker and a single record, got %d lines: %q", len(records), records)
+	}
+}
+
+// TestApply_Legacy verifies that deltas written in the positional format still reconstruct
+func TestApply_Legacy(t *testing.T) {
+	base := SplitLines([]byte("one\ntwo\r\nthree\n"))
+	legacy := []byte("4\n2 @@@ VFdP\n4 @@@ Zm91cg==\n")
+
+	got, err := Apply(base, legacy)
+	if err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	if want := "one\nTWO\nthree\nfour\n"; strings.Join(got, "") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(got, ""))
+	}
+}
+
+// TestApply_Corrupted verifies that malformed deltas are rejected
+func TestApply_Corrupted(t *testing.T) {
+	base := SplitLines([]byte("one\ntwo\n"))
+	for _, content := range []string{
+		Marker + "\n= 5\n",
+		Marker + "\n? 1\n",
+		"not a delta\n",
+	} {
+		if _, err := Apply(base, []byte(content)); err == nil {
+			t.Errorf("expected error for %q, got nil", content)
+		}
+	}
+}
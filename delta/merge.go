@@ -0,0 +1,81 @@
+package delta
+
+import "slices"
+
+// Lines framing the conflicting changes of both sides in a merged file, like git writes them
+const (
+	ConflictStart = "<<<<<<<"
+	ConflictSep   = "======="
+	ConflictEnd   = ">>>>>>>"
+)
+
+// Returns the positions of the lines of base in the other version, -1 for lines the other version removed.
+// The extra entry at the end maps the end of base to the end of the other version.
+func matchLines(base, other []string) []int {
+	match := make([]int, len(base)+1)
+	i, j := 0, 0
+	for _, e := range Diff(base, other) {
+		switch e.Op {
+		case Keep:
+			match[i] = j
+			i++
+			j++
+		case Delete:
+			match[i] = -1
+			i++
+		case Insert:
+			j++
+		}
+	}
+	match[len(base)] = len(other)
+	return match
+}
+
+// Merges the changes both sides made to base line by line. Regions changed by one side only take that change,
+// regions both sides changed differently are written between conflict markers labeled with the names of the sides.
+// Returns the merged lines and the number of conflicts.
+func Merge(base, ours, theirs []string, oursLabel, theirsLabel string) ([]string, int) {
+	matchOurs := matchLines(base, ours)
+	matchTheirs := matchLines(base, theirs)
+
+	var merged []string
+	conflicts := 0
+	i, o, t := 0, 0, 0
+	for {
+		// The next line of base both sides kept is stable, the lines before it on each side form a chunk
+		k := i
+		for k < len(base) && (matchOurs[k] < 0 || matchTheirs[k] < 0) {
+			k++
+		}
+		baseChunk, oursChunk, theirsChunk := base[i:k], ours[o:matchOurs[k]], theirs[t:matchTheirs[k]]
+
+		switch {
+		case slices.Equal(oursChunk, baseChunk):
+			merged = append(merged, theirsChunk...)
+		case slices.Equal(theirsChunk, baseChunk), slices.Equal(oursChunk, theirsChunk):
+			merged = append(merged, oursChunk...)
+		default:
+			conflicts++
+			merged = append(merged, ConflictStart+" "+oursLabel+"\n")
+			merged = appendTerminated(merged, oursChunk)
+			merged = append(merged, ConflictSep+"\n")
+			merged = appendTerminated(merged, theirsChunk)
+			merged = append(merged, ConflictEnd+" "+theirsLabel+"\n")
+		}
+
+		if k == len(base) {
+			return merged, conflicts
+		}
+		merged = append(merged, base[k])
+		i, o, t = k+1, matchOurs[k]+1, matchTheirs[k]+1
+	}
+}
+
+// Appends the lines, terminating the last one so that a conflict marker can follow it
+func appendTerminated(merged, lines []string) []string {
+	merged = append(merged, lines...)
+	if n := len(merged); n > 0 && merged[n-1][len(merged[n-1])-1] != '\n' {
+		merged[n-1] += "\n"
+	}
+	return merged
+}
@@ -0,0 +1,43 @@
+package delta
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMerge verifies that changes of one side are taken and overlapping changes of both sides conflict
+func TestMerge(t *testing.T) {
+	lines := func(s string) []string { return SplitLines([]byte(s)) }
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts int
+	}{
+		{"separate changes", "a\nb\nc\nd\n", "A\nb\nc\nd\n", "a\nb\nc\nD\n", "A\nb\nc\nD\n", 0},
+		{"insertions at both ends", "a\nb\n", "top\na\nb\n", "a\nb\nbottom\n", "top\na\nb\nbottom\n", 0},
+		{"same change on both sides", "a\nb\n", "a\nB\n", "a\nB\n", "a\nB\n", 0},
+		{"deletion and untouched", "a\nb\nc\n", "a\nc\n", "a\nb\nc\n", "a\nc\n", 0},
+		{"one side unchanged", "a\n", "a\n", "x\ny\n", "x\ny\n", 0},
+		{
+			"conflicting change", "a\nb\nc\n", "a\nours\nc\n", "a\ntheirs\nc\n",
+			"a\n<<<<<<< commit 1\nours\n=======\ntheirs\n>>>>>>> commit 2\nc\n", 1,
+		},
+		{
+			"conflict without final newline", "a\nb", "a\nours", "a\ntheirs",
+			"a\n<<<<<<< commit 1\nours\n=======\ntheirs\n>>>>>>> commit 2\n", 1,
+		},
+		{
+			"deleted on one side, changed on the other", "a\nb\nc\n", "a\nc\n", "a\nB\nc\n",
+			"a\n<<<<<<< commit 1\n=======\nB\n>>>>>>> commit 2\nc\n", 1,
+		},
+	}
+	for _, tc := range tests {
+		got, conflicts := Merge(lines(tc.base), lines(tc.ours), lines(tc.theirs), "commit 1", "commit 2")
+		if strings.Join(got, "") != tc.want || conflicts != tc.conflicts {
+			t.Errorf("%s: Merge() = %q, %d conflicts; want %q, %d conflicts", tc.name, strings.Join(got, ""), conflicts, tc.want, tc.conflicts)
+		}
+	}
+}
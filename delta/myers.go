@@ -0,0 +1,143 @@
+package delta
+
+// Computes the shortest edit script transforming lines a into lines b
+// using Myers' O(ND) algorithm with its linear space refinement
+func Diff(a, b []string) []Edit {
+
+	// Map every distinct line to an integer so that comparisons are cheap
+	ids := make(map[string]int)
+	d := &differ{
+		a:       intern(a, ids),
+		b:       intern(b, ids),
+		deleted: make([]bool, len(a)),
+		added:   make([]bool, len(b)),
+	}
+	d.compare(0, len(a), 0, len(b))
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && d.deleted[i]:
+			edits = append(edits, Edit{Op: Delete, Line: a[i]})
+			i++
+		case j < len(b) && d.added[j]:
+			edits = append(edits, Edit{Op: Insert, Line: b[j]})
+			j++
+		default:
+			edits = append(edits, Edit{Op: Keep, Line: a[i]})
+			i++
+			j++
+		}
+	}
+	return edits
+}
+
+type differ struct {
+	a, b    []int
+	deleted []bool
+	added   []bool
+}
+
+func intern(lines []string, ids map[string]int) []int {
+	out := make([]int, len(lines))
+	for i, line := range lines {
+		id, ok := ids[line]
+		if !ok {
+			id = len(ids)
+			ids[line] = id
+		}
+		out[i] = id
+	}
+	return out
+}
+
+// Marks deleted and added lines between a[aLo:aHi] and b[bLo:bHi]
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+
+	// Common prefix and suffix are always kept
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.added[j] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.deleted[i] = true
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(u, aHi, v, bHi)
+	}
+}
+
+// Finds the middle snake of an optimal path between a[aLo:aHi] and b[bLo:bHi],
+// returns its start (x, y) and end (u, v) points
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (int, int, int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+	off := limit + 1
+
+	// forward[k] is the furthest x reached on diagonal k from the start,
+	// backward[k] is the furthest distance from the end on the reversed diagonal k
+	forward := make([]int, 2*limit+3)
+	backward := make([]int, 2*limit+3)
+
+	for step := 0; step <= limit; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[off+k-1] < forward[off+k+1]) {
+				x = forward[off+k+1]
+			} else {
+				x = forward[off+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[off+k] = x
+
+			// Reverse diagonal of k is delta-k, it has already been explored step-1 times
+			if odd && delta-k >= -(step-1) && delta-k <= step-1 && x+backward[off+delta-k] >= n {
+				return aLo + startX, bLo + startY, aLo + x, bLo + y
+			}
+		}
+
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && backward[off+k-1] < backward[off+k+1]) {
+				x = backward[off+k+1]
+			} else {
+				x = backward[off+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			backward[off+k] = x
+
+			if !odd && delta-k >= -step && delta-k <= step && x+forward[off+delta-k] >= n {
+				return aHi - x, bHi - y, aHi - startX, bHi - startY
+			}
+		}
+	}
+
+	// Unreachable, an overlap is always found within limit steps. Fail instead of storing a wrong edit script
+	panic("myers: no middle snake found")
+}
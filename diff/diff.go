@@ -1,340 +1,180 @@
 package diff
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
-	"io"
 	"os"
-	"strconv"
 	"strings"
-	"time"
 
 	bh "github.com/mainak55512/qwe/binaryhandler"
-	cp "github.com/mainak55512/qwe/compressor"
+	dl "github.com/mainak55512/qwe/delta"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 	res "github.com/mainak55512/qwe/reconstruct"
 	tr "github.com/mainak55512/qwe/tracker"
 )
 
+// Change of a single line, Line is the 1-based line number
 type Changes struct {
+	Line int
 	Prev string
 	Curr string
 }
 
+// Outcome of comparing two versions of a file
+type Result struct {
+	Binary  bool      // Binary files are only compared as a whole
+	Changed bool      // Whether the two versions differ
+	Changes []Changes // Line by line changes of text files
+}
+
 // Determines the difference between two version of the file
-func Diff(filePath, commitID1Str, commitID2Str string) error {
+func Diff(root, filePath, commitID1Str, commitID2Str string) (Result, error) {
+	oldContent, newContent, isBin, err := versions(root, filePath, commitID1Str, commitID2Str)
+	if err != nil {
+		return Result{}, err
+	}
+	if isBin {
+		return Result{Binary: true, Changed: !bytes.Equal(oldContent, newContent)}, nil
+	}
+
+	oldLines := trimLines(dl.SplitLines(oldContent))
+	newLines := trimLines(dl.SplitLines(newContent))
+
+	var diff_content []Changes
+
+	// Check the differences line by line
+	for i, line := range newLines {
+		prev := ""
+		if i < len(oldLines) {
+			prev = oldLines[i]
+		}
+		if prev != line {
+			diff_content = append(diff_content, Changes{Line: i + 1, Prev: prev, Curr: line})
+		}
+	}
+	for i := len(newLines); i < len(oldLines); i++ {
+		if oldLines[i] != "" {
+			diff_content = append(diff_content, Changes{Line: i + 1, Prev: oldLines[i], Curr: ""})
+		}
+	}
+	return Result{Changed: len(diff_content) > 0, Changes: diff_content}, nil
+}
+
+// Removes line terminators
+func trimLines(lines []string) []string {
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(strings.TrimSuffix(lines[i], "\n"), "\r")
+	}
+	return lines
+}
+
+// Returns the content of the two versions of the file to compare and whether the file is binary.
+// Both commit IDs empty compares the uncommitted file with the current commit,
+// 'uncommitted' and a commit ID compares the uncommitted file with that commit,
+// two commit IDs compare the first commit with the second one. Commits may also be given by their tags.
+func versions(root, filePath, commitID1Str, commitID2Str string) ([]byte, []byte, bool, error) {
 
 	// Only allow if both are either empty or non-empty
 	if !((commitID1Str == "") == (commitID2Str == "")) {
-		return fmt.Errorf("Argument number missmatch")
+		return nil, nil, false, fmt.Errorf("Argument number missmatch")
 	}
 
-	// Get details from _tracker.qwe
-	tracker, _, err := tr.GetTracker(0)
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
 	if err != nil {
-		return err
+		return nil, nil, false, err
 	}
 
-	fileId := utl.Hasher(filePath)
-	fileObjectId := utl.Hasher(fmt.Sprintf("%s%d", filePath, time.Now().UnixNano()))
+	// Get details from _tracker.qwe
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return nil, nil, false, err
+	}
 
 	// Check if file is being tracked
+	fileId := utl.Hasher(filePath)
 	val, ok := tracker[fileId]
 	if !ok {
-		return er.FileNotTracked
+		return nil, nil, false, er.FileNotTracked
 	}
 
-	// Will run if no commit id is passed or both commit id is passed and first one is 'uncommitted'
-	if (commitID1Str == "" && commitID2Str == "") || commitID1Str == "uncommitted" {
-
-		target := ".qwe/_object/_diff_" + fileObjectId
+	// Commits may be given by their tags
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	isBin := strings.HasPrefix(val.Base, "_bin_")
 
+	// Will run if no commit id is passed or both commit id is passed and first one is 'uncommitted'
+	if commitID1Str == "" || commitID1Str == "uncommitted" {
+		commitID := -2 // base version unless the current version is a commit
 		if commitID2Str != "" {
-			commitID, err := strconv.Atoi(commitID2Str)
-			if err != nil {
-				return err
-			}
-
-			if strings.HasPrefix(val.Base, "_bin_") {
-
-				target_content, err := os.Create(target)
-				if err != nil {
-					return err
-				}
-				defer target_content.Close()
-				current_content, err := os.Open(".qwe/_object/" + val.Versions[commitID].UID)
-				if err != nil {
-					return err
-				}
-				defer current_content.Close()
-				buf := make([]byte, 1024)
-				_, err = io.CopyBuffer(target_content, current_content, buf)
-				if err != nil {
-					return err
-				}
-				if err := cp.DecompressFile(target); err != nil {
-					os.Remove(target)
-					return err
-				}
-				isEq, err := bh.CheckBinDiff(target, filePath)
-				if err != nil {
-					os.Remove(target)
-					return err
-				}
-				if isEq {
-					fmt.Println("File content is same!")
-				} else {
-					fmt.Println("File content changed!")
-				}
-				os.Remove(target)
-				return nil
-
-			}
-			// Reconstruct till the specified commit id
-			if err = res.Reconstruct(val, target, commitID); err != nil {
-				return err
+			if commitID, err = parseCommitID(meta.Tags, fileId, val, commitID2Str); err != nil {
+				return nil, nil, false, err
 			}
 		} else {
-			if strings.HasPrefix(val.Base, "_bin_") {
-
-				target_content, err := os.Create(target)
-				if err != nil {
-					return err
-				}
-				defer target_content.Close()
-				current_content, err := os.Open(".qwe/_object/" + val.Current)
-				if err != nil {
-					return err
-				}
-				defer current_content.Close()
-				buf := make([]byte, 1024)
-				_, err = io.CopyBuffer(target_content, current_content, buf)
-				if err != nil {
-					return err
-				}
-				if err := cp.DecompressFile(target); err != nil {
-					os.Remove(target)
-					return err
-				}
-				isEq, err := bh.CheckBinDiff(target, filePath)
-				if err != nil {
-					os.Remove(target)
-					return err
-				}
-				if isEq {
-					fmt.Println("File content is same!")
-				} else {
-					fmt.Println("File content changed!")
-				}
-				os.Remove(target)
-				return nil
-
-			}
-			// Reconstruct till the current version
-			var commitID int = -1
 			for i := range val.Versions {
 				if val.Versions[i].UID == val.Current {
 					commitID = i
 				}
 			}
-			if err = res.Reconstruct(val, target, commitID); err != nil {
-				return err
-			}
-		}
-
-		// As commitID1Str is either empty or 'uncommitted', need to compare uncommited changes of the file
-		// with the latest committed version or with the version specified by the commitID2Str
-		new_file, err := os.Open(filePath)
-		if err != nil {
-			return err
 		}
-		defer new_file.Close()
-
-		current_file, err := os.Open(target)
+		oldContent, err := version(root, val, commitID)
 		if err != nil {
-			return err
+			return nil, nil, false, err
 		}
 
-		current_scanner := bufio.NewScanner(current_file)
-
-		new_scanner := bufio.NewScanner(new_file)
-
-		var diff_content []Changes
-
-		// Check the differences
-		line := 0
-		for new_scanner.Scan() {
-			line++
-			current_scanner.Scan()
-			if !bytes.Equal(current_scanner.Bytes(), new_scanner.Bytes()) {
-				diff_content = append(diff_content, Changes{
-					Prev: fmt.Sprintf("- %d %s", line, current_scanner.Text()),
-					Curr: fmt.Sprintf("+ %d %s", line, new_scanner.Text()),
-				})
-			}
-		}
-		for current_scanner.Scan() {
-			line++
-			if !bytes.Equal(current_scanner.Bytes(), []byte("")) {
-				diff_content = append(diff_content, Changes{
-					Prev: fmt.Sprintf("+ %d %s", line, current_scanner.Text()),
-					Curr: fmt.Sprintf("- %d %s", line, ""),
-				})
-			}
-		}
-
-		current_file.Close()
-		os.Remove(target)
-		if len(diff_content) == 0 {
-			fmt.Println("No Change!")
-		} else {
-			fmt.Printf("===Start Diff view===\n\n")
-			for _, elem := range diff_content {
-				fmt.Println(elem.Prev + "\n" + elem.Curr)
-				fmt.Println()
-			}
-			fmt.Printf("\n===End of Diff===")
-		}
-	} else {
-
-		// This part will execute if both commitIDs are supplied through the command line
-		var commit1, commit2 int
-		if commitID1Str != "" {
-			commit1, err = strconv.Atoi(commitID1Str)
-			if err != nil {
-				return err
-			}
-		}
-		if commitID2Str != "" {
-			commit2, err = strconv.Atoi(commitID2Str)
-			if err != nil {
-				return err
-			}
-		}
-		src := ".qwe/_object/_diff_src_" + fileObjectId
-		dest := ".qwe/_object/_diff_dest_" + fileObjectId
-
-		if strings.HasPrefix(val.Base, "_bin_") {
-			src_content, err := os.Create(src)
-			if err != nil {
-				return err
-			}
-			defer src_content.Close()
-			dest_content, err := os.Create(dest)
-			if err != nil {
-				return err
-			}
-			defer dest_content.Close()
-			current_src_content, err := os.Open(".qwe/_object/" + val.Versions[commit1].UID)
-			if err != nil {
-				return err
-			}
-			defer current_src_content.Close()
-			current_dest_content, err := os.Open(".qwe/_object/" + val.Versions[commit2].UID)
-			if err != nil {
-				return err
-			}
-			defer current_dest_content.Close()
-			buf := make([]byte, 1024)
-			_, err = io.CopyBuffer(src_content, current_src_content, buf)
-			if err != nil {
-				return err
-			}
-			_, err = io.CopyBuffer(dest_content, current_dest_content, buf)
-			if err != nil {
-				return err
-			}
-			if err := cp.DecompressFile(src); err != nil {
-				os.Remove(src)
-				os.Remove(dest)
-				return err
-			}
-			if err := cp.DecompressFile(dest); err != nil {
-				os.Remove(src)
-				os.Remove(dest)
-				return err
-			}
-			isEq, err := bh.CheckBinDiff(src, dest)
-			if err != nil {
-				os.Remove(src)
-				os.Remove(dest)
-				return err
-			}
-			if isEq {
-				fmt.Println("File content is same!")
-			} else {
-				fmt.Println("File content changed!")
-			}
-			os.Remove(src)
-			os.Remove(dest)
-			return nil
-		}
-		// reconstruct till first commitID
-		if err = res.Reconstruct(val, src, commit1); err != nil {
-			return err
-		}
-
-		// Reconstruct till second commitID
-		if err = res.Reconstruct(val, dest, commit2); err != nil {
-			return err
-		}
-		new_file, err := os.Open(dest)
-		if err != nil {
-			return err
-		}
-		defer new_file.Close()
-
-		current_file, err := os.Open(src)
+		// As commitID1Str is either empty or 'uncommitted', need to compare uncommited changes of the file
+		newContent, err := os.ReadFile(utl.WorkPath(root, filePath))
 		if err != nil {
-			return err
+			return nil, nil, false, err
 		}
-		// defer current_file.Close()
-		current_scanner := bufio.NewScanner(current_file)
-
-		new_scanner := bufio.NewScanner(new_file)
+		return oldContent, newContent, isBin, nil
+	}
 
-		var diff_content []Changes
+	// This part will execute if both commitIDs are supplied
+	commit1, err := parseCommitID(meta.Tags, fileId, val, commitID1Str)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	commit2, err := parseCommitID(meta.Tags, fileId, val, commitID2Str)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	oldContent, err := version(root, val, commit1)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	newContent, err := version(root, val, commit2)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return oldContent, newContent, isBin, nil
+}
 
-		// Compare for changes
-		line := 0
-		for new_scanner.Scan() {
-			line++
-			current_scanner.Scan()
-			if !bytes.Equal(current_scanner.Bytes(), new_scanner.Bytes()) {
-				diff_content = append(diff_content, Changes{
-					Prev: fmt.Sprintf("- %d %s", line, current_scanner.Text()),
-					Curr: fmt.Sprintf("+ %d %s", line, new_scanner.Text()),
-				})
-			}
-		}
-		for current_scanner.Scan() {
-			line++
-			if !bytes.Equal(current_scanner.Bytes(), []byte("")) {
-				diff_content = append(diff_content, Changes{
-					Prev: fmt.Sprintf("+ %d %s", line, current_scanner.Text()),
-					Curr: fmt.Sprintf("- %d %s", line, ""),
-				})
-			}
+// Returns the content of the file at the commitID, -2 refers to the base version
+func version(root string, val tr.Tracker, commitID int) ([]byte, error) {
+	if strings.HasPrefix(val.Base, "_bin_") {
+		if commitID >= 0 {
+			return bh.ReadVersion(root, val.Versions[commitID].Object(), val.Versions[commitID].DeltaOf)
 		}
-		// diff_content = fmt.Sprintf("%d\n%s", line, diff_content)
-		current_file.Close()
-
-		// Remove temporary files
-		os.Remove(src)
-		os.Remove(dest)
+		return res.ReadObject(root, val.Base)
+	}
+	lines, err := res.Lines(root, val, commitID)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
 
-		if len(diff_content) == 0 {
-			fmt.Println("No Change!")
-		} else {
-			fmt.Printf("===Start Diff view===\n\n")
-			for _, elem := range diff_content {
-				fmt.Println(elem.Prev + "\n" + elem.Curr)
-				fmt.Println()
-			}
-			fmt.Printf("\n===End of Diff===")
-		}
+func parseCommitID(tags tr.Tags, fileId string, val tr.Tracker, commitIDStr string) (int, error) {
+	commitID, err := tags.ResolveFile(fileId, val, commitIDStr)
+	if err != nil {
+		return 0, err
+	}
+	if commitID < 0 || commitID > len(val.Versions)-1 {
+		return 0, er.InvalidCommitNo
 	}
-	return nil
+	return commitID, nil
 }
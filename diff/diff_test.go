@@ -2,12 +2,14 @@ package diff
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
 	in "github.com/mainak55512/qwe/initializer"
 	er "github.com/mainak55512/qwe/qwerror"
+	tr "github.com/mainak55512/qwe/tracker"
 )
 
 func TestDiffArgumentValidation(t *testing.T) {
@@ -50,7 +52,7 @@ func TestDiffArgumentValidation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := Diff(tt.filePath, tt.commitID1, tt.commitID2)
+			_, err := Diff(".", tt.filePath, tt.commitID1, tt.commitID2)
 
 			if tt.expectError {
 				if err == nil {
@@ -74,7 +76,7 @@ func TestDiffFileNotTracked(t *testing.T) {
 	}
 
 	// Try to diff an untracked file (should fail with FileNotTracked)
-	err := Diff(testFile, "", "")
+	_, err := Diff(tempDirPath, testFile, "", "")
 
 	// Verify we get the specific FileNotTracked error
 	if err == nil {
@@ -86,6 +88,71 @@ func TestDiffFileNotTracked(t *testing.T) {
 	}
 }
 
+func TestHunksContext(t *testing.T) {
+	var a []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprintf("%d\n", i))
+	}
+	b := append([]string{}, a...)
+	b[1] = "two\n"
+	b[3] = "four\n"
+	b[17] = "eighteen\n"
+
+	hunks := Hunks(a, b, 3)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	if h := hunks[0]; h.OldStart != 1 || h.OldLines != 7 || h.NewStart != 1 || h.NewLines != 7 {
+		t.Errorf("unexpected first hunk range: %+v", h)
+	}
+	if h := hunks[1]; h.OldStart != 15 || h.OldLines != 6 || h.NewStart != 15 || h.NewLines != 6 {
+		t.Errorf("unexpected second hunk range: %+v", h)
+	}
+
+	if hunks := Hunks(a, a, 3); len(hunks) != 0 {
+		t.Errorf("expected no hunks for identical content, got %d", len(hunks))
+	}
+}
+
+func TestFormatUnified(t *testing.T) {
+	a := []string{"one\n", "two\n"}
+	b := []string{"zero\n", "one\n", "two"}
+
+	want := "--- a/test.txt\n+++ b/test.txt\n" +
+		"@@ -1,2 +1,3 @@\n" +
+		"+zero\n" +
+		" one\n" +
+		"-two\n" +
+		"+two\n" +
+		"\\ No newline at end of file\n"
+	if got := FormatUnified("a/test.txt", "b/test.txt", Hunks(a, b, 3)); got != want {
+		t.Errorf("unexpected unified diff\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestUnifiedUncommitted(t *testing.T) {
+	_, cleanup := initQwe(t)
+	defer cleanup()
+
+	if err := os.WriteFile("notes.txt", []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if _, err := tr.StartTracking(".", "notes.txt"); err != nil {
+		t.Fatalf("failed to track test file: %v", err)
+	}
+	if err := os.WriteFile("notes.txt", []byte("a\nB\nc\n"), 0644); err != nil {
+		t.Fatalf("failed to update test file: %v", err)
+	}
+
+	hunks, err := Unified(".", "notes.txt", "", "", 0)
+	if err != nil {
+		t.Fatalf("Unified() failed: %v", err)
+	}
+	if len(hunks) != 1 || hunks[0].OldStart != 2 || hunks[0].NewStart != 2 || len(hunks[0].Lines) != 2 {
+		t.Errorf("unexpected hunks: %+v", hunks)
+	}
+}
+
 // initQwe creates a temp directory, initializes qwe repository, and changes to that directory.
 // Returns the temp directory path and a cleanup function.
 // The cleanup function restores the original directory and removes the temp directory.
@@ -116,7 +183,7 @@ func initQwe(t *testing.T) (tempDirPath string, cleanup func()) {
 	}
 
 	// Initialize qwe repository in temp directory
-	if err := in.Init(); err != nil {
+	if err := in.Init("."); err != nil {
 		os.Chdir(originalDir)
 		os.RemoveAll(tempDirPath)
 		t.Fatalf("failed to initialize qwe repository: %v", err)
@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	dl "github.com/mainak55512/qwe/delta"
+	er "github.com/mainak55512/qwe/qwerror"
+)
+
+// Number of unchanged lines shown around every change by default
+const DefaultContext = 3
+
+// Contiguous region of changes between two versions of a file,
+// start line numbers are 1-based as in the unified diff format
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []dl.Edit
+}
+
+// Groups the changes between lines a and b into hunks with the given number of context lines
+func Hunks(a, b []string, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+	edits := dl.Diff(a, b)
+
+	// oldPos[i] and newPos[i] are the number of lines of a and b consumed before edits[i]
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.Op != dl.Insert {
+			oldPos[i+1]++
+		}
+		if e.Op != dl.Delete {
+			newPos[i+1]++
+		}
+	}
+
+	// Ranges of edits covered by each hunk, changes closer than twice the context share a hunk
+	var ranges [][2]int
+	for i, e := range edits {
+		if e.Op == dl.Keep {
+			continue
+		}
+		start, end := max(0, i-context), min(len(edits), i+context+1)
+		if n := len(ranges); n > 0 && start <= ranges[n-1][1] {
+			ranges[n-1][1] = end
+		} else {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+
+	hunks := make([]Hunk, 0, len(ranges))
+	for _, r := range ranges {
+		hunk := Hunk{
+			OldStart: oldPos[r[0]] + 1,
+			OldLines: oldPos[r[1]] - oldPos[r[0]],
+			NewStart: newPos[r[0]] + 1,
+			NewLines: newPos[r[1]] - newPos[r[0]],
+			Lines:    edits[r[0]:r[1]],
+		}
+
+		// Empty ranges refer to the line preceding them
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// Renders hunks in the unified diff format understood by patch and other tools
+func FormatUnified(oldName, newName string, hunks []Hunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(hunk.OldStart, hunk.OldLines), hunkRange(hunk.NewStart, hunk.NewLines))
+		for _, line := range hunk.Lines {
+			prefix := " "
+			if line.Op == dl.Delete {
+				prefix = "-"
+			} else if line.Op == dl.Insert {
+				prefix = "+"
+			}
+			sb.WriteString(prefix + line.Line)
+			if !strings.HasSuffix(line.Line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Returns the hunks between two versions of a tracked text file,
+// commit IDs are interpreted the same way as in Diff
+func Unified(root, filePath, commitID1Str, commitID2Str string, context int) ([]Hunk, error) {
+	oldContent, newContent, isBin, err := versions(root, filePath, commitID1Str, commitID2Str)
+	if err != nil {
+		return nil, err
+	}
+	if isBin {
+		return nil, er.BinFileErr
+	}
+	return Hunks(dl.SplitLines(oldContent), dl.SplitLines(newContent), context), nil
+}
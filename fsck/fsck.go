@@ -0,0 +1,360 @@
+package fsck
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	cp "github.com/mainak55512/qwe/compressor"
+	dl "github.com/mainak55512/qwe/delta"
+	ob "github.com/mainak55512/qwe/object"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Kinds of problems reported by Check
+const (
+	MissingObject  = "missing_object"
+	CorruptObject  = "corrupt_object"
+	HashMismatch   = "hash_mismatch"
+	BrokenChain    = "broken_chain"
+	InvalidCurrent = "invalid_current"
+	InvalidBranch  = "invalid_branch"
+	InvalidTag     = "invalid_tag"
+	GroupMismatch  = "group_mismatch"
+	OrphanedObject = "orphaned_object"
+)
+
+// Single integrity problem found in the repository
+type Problem struct {
+	Kind    string `json:"kind"`
+	FileID  string `json:"file_id,omitempty"`
+	File    string `json:"file,omitempty"`
+	Group   string `json:"group,omitempty"`
+	Object  string `json:"object,omitempty"`
+	Commit  *int   `json:"commit,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result of an integrity check of a repository
+type Report struct {
+	Files    int       `json:"files"`
+	Groups   int       `json:"groups"`
+	Objects  int       `json:"objects"`
+	Problems []Problem `json:"problems"`
+}
+
+// Reports whether no problem was found
+func (r Report) Healthy() bool {
+	return len(r.Problems) == 0
+}
+
+// State of the checks of a repository
+type checker struct {
+	root    string
+	report  Report
+	names   map[string]string // file id to file name, as far as the groups know them
+	checked map[string]error  // result of verifying each object
+}
+
+func (c *checker) add(problem Problem) {
+	if problem.FileID != "" && problem.File == "" {
+		problem.File = c.names[problem.FileID]
+	}
+	c.report.Problems = append(c.report.Problems, problem)
+}
+
+// Checks the integrity of the repository at root: every object referenced by the trackers must exist,
+// decompress and match its content ID, the version chain of every text file must replay,
+// group commits must agree with the file tracker and every object must be referenced.
+func Check(root string) (Report, error) {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return Report{}, err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return Report{}, err
+	}
+
+	c := &checker{
+		root:    root,
+		names:   make(map[string]string),
+		checked: make(map[string]error),
+		report:  Report{Files: len(tracker), Groups: len(groupTracker), Problems: []Problem{}},
+	}
+	for _, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			for fileID, file := range version.Files {
+				c.names[fileID] = file.FileName
+			}
+		}
+	}
+
+	for _, fileID := range sortedKeys(tracker) {
+		c.checkFile(fileID, tracker[fileID])
+	}
+	for _, groupID := range sortedKeys(groupTracker) {
+		c.checkGroup(groupTracker[groupID], tracker)
+	}
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return c.report, err
+	}
+	c.checkTags(meta.Tags, tracker, groupTracker)
+	if err := c.checkOrphans(tr.Reachable(tracker, groupTracker)); err != nil {
+		return c.report, err
+	}
+	return c.report, nil
+}
+
+// Returns the decompressed content of an object, verifying its content ID
+func (c *checker) readObject(objID string) ([]byte, error) {
+	content, err := cp.ReadFile(utl.ObjectPath(c.root, objID))
+	if err != nil {
+		return nil, err
+	}
+	if utl.IsContentID(objID) && utl.ContentID(content) != utl.ObjectHash(objID) {
+		return nil, errHashMismatch
+	}
+	return content, nil
+}
+
+var errHashMismatch = errors.New("content does not match the object ID")
+
+// Reports a problem for an object that can not be read, returns false in that case
+func (c *checker) verifyObject(fileID, objID string, commit *int) ([]byte, bool) {
+	content, err := c.readObject(objID)
+	if err == nil {
+		return content, true
+	}
+
+	// An object shared by several files is reported once
+	if _, seen := c.checked[objID]; seen {
+		return nil, false
+	}
+	c.checked[objID] = err
+
+	problem := Problem{FileID: fileID, Object: objID, Commit: commit}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		problem.Kind, problem.Message = MissingObject, "object does not exist"
+	case errors.Is(err, errHashMismatch):
+		problem.Kind, problem.Message = HashMismatch, err.Error()
+	default:
+		problem.Kind, problem.Message = CorruptObject, fmt.Sprintf("object can not be decompressed: %v", err)
+	}
+	c.add(problem)
+	return nil, false
+}
+
+// Verifies the objects of a file and replays its version chain
+func (c *checker) checkFile(fileID string, val tr.Tracker) {
+	isBin := strings.HasPrefix(val.Base, "_bin_")
+
+	if val.Current != val.Base {
+		found := false
+		for _, version := range val.Versions {
+			found = found || version.UID == val.Current
+		}
+		if !found {
+			c.add(Problem{Kind: InvalidCurrent, FileID: fileID, Message: fmt.Sprintf("current version %s is neither the base nor a commit", val.Current)})
+		}
+	}
+	for _, name := range sortedKeys(val.Branches) {
+		if val.CommitNumber(val.Branches[name]) == -3 {
+			c.add(Problem{Kind: InvalidBranch, FileID: fileID, Message: fmt.Sprintf("head %s of branch %s is neither the base nor a commit", val.Branches[name], name)})
+		}
+	}
+
+	base, baseOk := c.verifyObject(fileID, val.Base, nil)
+
+	// Lines of the versions whose children are still to be replayed and whether they could be reconstructed,
+	// a version is dropped once its last child is replayed
+	replayed := map[int][]string{-2: dl.SplitLines(base)}
+	valid := map[int]bool{-2: baseOk}
+	children := make(map[int]int)
+	for i := range val.Versions {
+		children[val.Parent(i)]++
+	}
+
+	for i, version := range val.Versions {
+		commit := i
+		var lines []string
+		ok := false
+		if parent := val.Parent(i); parent == -3 || parent >= i {
+			c.add(Problem{Kind: BrokenChain, FileID: fileID, Commit: &commit, Message: fmt.Sprintf("parent %s of the version is no earlier version", version.Parent)})
+		} else {
+			lines, ok = replayed[parent], valid[parent]
+			if children[parent]--; children[parent] == 0 {
+				delete(replayed, parent)
+				delete(valid, parent)
+			}
+		}
+
+		content, objOk := c.verifyObject(fileID, version.Object(), &commit)
+		if isBin {
+			// Binary versions are full copies or deltas of a full copy
+			if objOk && version.DeltaOf != "" {
+				c.checkBinaryDelta(fileID, version, content, commit)
+			}
+			continue
+		}
+		if !objOk || !ok {
+			ok = false
+		} else {
+			// Text versions are deltas on top of their parent
+			var err error
+			if lines, err = dl.Apply(lines, content); err != nil {
+				c.add(Problem{Kind: BrokenChain, FileID: fileID, Object: version.Object(), Commit: &commit, Message: fmt.Sprintf("version can not be reconstructed: %v", err)})
+				ok = false
+			}
+		}
+
+		// Keyframes must match the replayed version, its descendants are reconstructed from them
+		if version.Snapshot != "" {
+			if snapshot, snapshotOk := c.verifyObject(fileID, version.Snapshot, &commit); snapshotOk {
+				snapshotLines := dl.SplitLines(snapshot)
+				if ok && !slices.Equal(lines, snapshotLines) {
+					c.add(Problem{Kind: BrokenChain, FileID: fileID, Object: version.Snapshot, Commit: &commit, Message: "keyframe does not match the replayed version"})
+				}
+				lines, ok = snapshotLines, true
+			}
+		}
+		if children[i] > 0 {
+			replayed[i], valid[i] = lines, ok
+		}
+	}
+}
+
+// Verifies that a binary delta applies to its full copy and yields the content the version recorded
+func (c *checker) checkBinaryDelta(fileID string, version tr.VersionDetails, delta []byte, commit int) {
+	source, ok := c.verifyObject(fileID, version.DeltaOf, &commit)
+	if !ok {
+		return
+	}
+	content, err := dl.ApplyBinary(source, delta)
+	if err == nil && version.Hash != "" && utl.ContentID(content) != version.Hash {
+		err = errHashMismatch
+	}
+	if err != nil {
+		c.add(Problem{Kind: BrokenChain, FileID: fileID, Object: version.Object(), Commit: &commit, Message: fmt.Sprintf("version can not be reconstructed: %v", err)})
+	}
+}
+
+// Verifies that the files of every group commit refer to existing versions in the file tracker
+func (c *checker) checkGroup(gr tr.GroupTracker, tracker tr.TrackerSchema) {
+	if _, ok := gr.Versions[gr.Current]; !ok {
+		c.add(Problem{Kind: GroupMismatch, Group: gr.GroupName, Message: fmt.Sprintf("current group commit %s does not exist", gr.Current)})
+	}
+	for _, name := range sortedKeys(gr.Branches) {
+		if _, ok := gr.Versions[gr.Branches[name]]; !ok {
+			c.add(Problem{Kind: InvalidBranch, Group: gr.GroupName, Message: fmt.Sprintf("head %s of branch %s is no group commit", gr.Branches[name], name)})
+		}
+	}
+	for i, versionID := range gr.VersionOrder {
+		commit := i
+		version, ok := gr.Versions[versionID]
+		if !ok {
+			c.add(Problem{Kind: GroupMismatch, Group: gr.GroupName, Commit: &commit, Message: "group commit does not exist"})
+			continue
+		}
+		if _, ok := gr.Versions[version.Parent]; version.Parent != "" && !ok {
+			c.add(Problem{Kind: GroupMismatch, Group: gr.GroupName, Commit: &commit, Message: fmt.Sprintf("parent group commit %s does not exist", version.Parent)})
+		}
+		for _, fileID := range sortedKeys(version.Files) {
+			file := version.Files[fileID]
+			problem := Problem{Kind: GroupMismatch, Group: gr.GroupName, FileID: fileID, File: file.FileName, Commit: &commit}
+
+			if fileID != utl.Hasher(file.FileName) {
+				problem.Message = "file is not stored under the hash of its name"
+				c.add(problem)
+				continue
+			}
+			val, ok := tracker[fileID]
+			if !ok {
+				problem.Message = "file is not tracked"
+				c.add(problem)
+				continue
+			}
+
+			switch {
+			case file.CommitNumber == -2:
+				if file.FileObjID != val.Base {
+					problem.Message = fmt.Sprintf("base version %s of the file is %s", file.FileObjID, val.Base)
+					c.add(problem)
+				}
+			case file.CommitNumber >= 0 && file.CommitNumber < len(val.Versions):
+				if uid := val.Versions[file.CommitNumber].UID; file.FileObjID != uid {
+					problem.Message = fmt.Sprintf("commit %d of the file is %s, not %s", file.CommitNumber, uid, file.FileObjID)
+					c.add(problem)
+				}
+			default:
+				problem.Message = fmt.Sprintf("file has no commit %d", file.CommitNumber)
+				c.add(problem)
+			}
+		}
+	}
+}
+
+// Reports the files of the object store that no tracker references
+func (c *checker) checkOrphans(referenced map[string]bool) error {
+	stored, err := ob.List(c.root)
+	if err != nil {
+		return err
+	}
+	objectDir := utl.QwePath(c.root, "_object")
+	var orphans []string
+	for _, file := range stored {
+		c.report.Objects++
+		if !referenced[file.Name] || !file.IsObject(c.root) {
+			rel, _ := filepath.Rel(objectDir, file.Path)
+			orphans = append(orphans, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(orphans)
+	for _, orphan := range orphans {
+		c.add(Problem{Kind: OrphanedObject, Object: orphan, Message: "object is not referenced by any tracker"})
+	}
+	return nil
+}
+
+// Returns the keys of a map in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Checks that every tag names a commit of a tracked file or of a group
+func (c *checker) checkTags(tags tr.Tags, tracker tr.TrackerSchema, groupTracker tr.GroupTrackerSchema) {
+	for _, fileID := range sortedKeys(tags.Files) {
+		val, tracked := tracker[fileID]
+		for _, name := range sortedKeys(tags.Files[fileID]) {
+			versionID := tags.Files[fileID][name]
+			if !tracked {
+				c.add(Problem{Kind: InvalidTag, FileID: fileID, Message: fmt.Sprintf("tag %s belongs to a file that is not tracked", name)})
+			} else if val.CommitNumber(versionID) == -3 {
+				c.add(Problem{Kind: InvalidTag, FileID: fileID, Message: fmt.Sprintf("tag %s names %s which is neither the base nor a commit", name, versionID)})
+			}
+		}
+	}
+	for _, groupID := range sortedKeys(tags.Groups) {
+		gr, ok := groupTracker[groupID]
+		for _, name := range sortedKeys(tags.Groups[groupID]) {
+			versionID := tags.Groups[groupID][name]
+			if !ok {
+				c.add(Problem{Kind: InvalidTag, Message: fmt.Sprintf("tag %s belongs to group id %s which does not exist", name, groupID)})
+			} else if _, exists := gr.Versions[versionID]; !exists {
+				c.add(Problem{Kind: InvalidTag, Group: gr.GroupName, Message: fmt.Sprintf("tag %s names %s which is no group commit", name, versionID)})
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package fsck
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// newRepo creates a repository with a file committed twice and tracked in a group
+func newRepo(t *testing.T) (string, tr.Tracker) {
+	t.Helper()
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := os.WriteFile(notes, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.GroupInit(root, "docs"); err != nil {
+		t.Fatalf("GroupInit() failed: %v", err)
+	}
+	if _, err := tr.StartGroupTracking(root, "docs", []string{"notes.txt"}, false); err != nil {
+		t.Fatalf("StartGroupTracking() failed: %v", err)
+	}
+	for _, content := range []string{"a\nb\n", "a\nb\nc\n"} {
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := cm.CommitGroup(root, "docs", "update"); err != nil {
+			t.Fatalf("CommitGroup() failed: %v", err)
+		}
+	}
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		t.Fatalf("GetTracker() failed: %v", err)
+	}
+	return root, tracker[utl.Hasher("notes.txt")]
+}
+
+// kinds returns how many problems of each kind the report has
+func kinds(report Report) map[string]int {
+	count := make(map[string]int)
+	for _, problem := range report.Problems {
+		count[problem.Kind]++
+	}
+	return count
+}
+
+// TestCheck_Healthy tests that a freshly used repository has no problems
+func TestCheck_Healthy(t *testing.T) {
+	root, _ := newRepo(t)
+	report, err := Check(root)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if !report.Healthy() || report.Files != 1 || report.Groups != 1 || report.Objects != 3 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+// TestCheck_Problems tests that missing, corrupt and orphaned objects, broken group references and dangling tags are reported
+func TestCheck_Problems(t *testing.T) {
+	root, val := newRepo(t)
+
+	// The first commit is corrupted, hence the second one can not be replayed either but is not reported on its own
+	if err := os.WriteFile(utl.ObjectPath(root, val.Versions[0].ObjID), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(utl.ObjectPath(root, val.Versions[1].ObjID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(utl.QwePath(root, "_object", "stray"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A group commit pointing to a commit the file does not have
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr := groupTracker[utl.Hasher("docs")]
+	files := gr.Versions[gr.Current].Files
+	file := files[utl.Hasher("notes.txt")]
+	file.CommitNumber = 5
+	files[utl.Hasher("notes.txt")] = file
+	content, _ := json.Marshal(groupTracker)
+	if err := tr.SaveTracker(root, 1, content); err != nil {
+		t.Fatal(err)
+	}
+
+	// A tag naming a version the file does not have
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Tags.Files = map[string]map[string]string{utl.Hasher("notes.txt"): {"gone": "missing"}}
+	if err := tr.SaveMeta(root, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Check(root)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	want := map[string]int{CorruptObject: 1, MissingObject: 1, OrphanedObject: 1, GroupMismatch: 1, InvalidTag: 1}
+	got := kinds(report)
+	for kind, count := range want {
+		if got[kind] != count {
+			t.Errorf("expected %d %s problems, got %d: %+v", count, kind, got[kind], report.Problems)
+		}
+	}
+	if len(report.Problems) != 5 {
+		t.Errorf("expected 5 problems, got %+v", report.Problems)
+	}
+	for _, problem := range report.Problems {
+		if problem.FileID != "" && problem.File != "notes.txt" {
+			t.Errorf("file name not resolved for %+v", problem)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package gc
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	ob "github.com/mainak55512/qwe/object"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Outcome of a garbage collection
+type Result struct {
+	Removed        []string `json:"removed"` // paths relative to the object store
+	ReclaimedBytes int64    `json:"reclaimed_bytes"`
+}
+
+// Removes the objects of the repository at root that neither tracker references,
+// along with temporary files left in the object store by interrupted commands.
+// With dryRun nothing is removed, the result tells what would be.
+func Collect(root string, dryRun bool) (Result, error) {
+	result := Result{Removed: []string{}}
+
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return result, err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return result, err
+	}
+	reachable := tr.Reachable(tracker, groupTracker)
+
+	stored, err := ob.List(root)
+	if err != nil {
+		return result, err
+	}
+	objectDir := utl.QwePath(root, "_object")
+	dirs := make(map[string]bool)
+	for _, file := range stored {
+		if reachable[file.Name] && file.IsObject(root) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(file.Path); err != nil {
+				return result, err
+			}
+			dirs[filepath.Dir(file.Path)] = true
+		}
+		rel, _ := filepath.Rel(objectDir, file.Path)
+		result.Removed = append(result.Removed, filepath.ToSlash(rel))
+		result.ReclaimedBytes += file.Size
+	}
+	sort.Strings(result.Removed)
+
+	// Fan-out folders left empty are removed as well, os.Remove fails on folders that are not empty
+	for dir := range dirs {
+		if dir != objectDir {
+			os.Remove(dir)
+		}
+	}
+	return result, nil
+}
+
+// Removes the candidate objects that neither tracker references anymore,
+// used once entries have been removed from the trackers. Objects shared with other entries are kept.
+func Prune(root string, candidates []string) (Result, error) {
+	result := Result{Removed: []string{}}
+
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return result, err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return result, err
+	}
+	reachable := tr.Reachable(tracker, groupTracker)
+
+	objectDir := utl.QwePath(root, "_object")
+	seen := make(map[string]bool)
+	for _, objID := range candidates {
+		if objID == "" || reachable[objID] || seen[objID] {
+			continue
+		}
+		seen[objID] = true
+		objPath := utl.ObjectPath(root, objID)
+		info, err := os.Stat(objPath)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(objPath); err != nil {
+			return result, err
+		}
+		if dir := filepath.Dir(objPath); dir != objectDir {
+			os.Remove(dir)
+		}
+		rel, _ := filepath.Rel(objectDir, objPath)
+		result.Removed = append(result.Removed, filepath.ToSlash(rel))
+		result.ReclaimedBytes += info.Size()
+	}
+	sort.Strings(result.Removed)
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package gc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	ob "github.com/mainak55512/qwe/object"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestCollect removes unreachable objects and temporary files only
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := os.WriteFile(notes, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	if err := os.WriteFile(notes, []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cm.CommitUnit(root, "notes.txt", "second line"); err != nil {
+		t.Fatalf("CommitUnit() failed: %v", err)
+	}
+
+	// Garbage: an object of an aborted commit and temporary files
+	orphan, err := ob.Write(root, "", []byte("never committed"))
+	if err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	for _, name := range []string{"_diff_src_123", "_tracker.qwe.tmp"} {
+		if err := os.WriteFile(utl.QwePath(root, "_object", name), []byte("temp"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dryRun, err := Collect(root, true)
+	if err != nil {
+		t.Fatalf("Collect(dry run) failed: %v", err)
+	}
+	if len(dryRun.Removed) != 3 || dryRun.ReclaimedBytes == 0 {
+		t.Fatalf("unexpected dry run result: %+v", dryRun)
+	}
+	if !utl.FileExists(utl.ObjectPath(root, orphan)) {
+		t.Fatalf("dry run removed %s", orphan)
+	}
+
+	result, err := Collect(root, false)
+	if err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+	if strings.Join(result.Removed, ",") != strings.Join(dryRun.Removed, ",") || result.ReclaimedBytes != dryRun.ReclaimedBytes {
+		t.Errorf("result %+v differs from dry run %+v", result, dryRun)
+	}
+	if utl.FolderExists(filepath.Dir(utl.ObjectPath(root, orphan))) {
+		t.Errorf("empty fan-out folder of %s was not removed", orphan)
+	}
+
+	// The history of the tracked file is intact
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := res.Lines(root, tracker[utl.Hasher("notes.txt")], -1)
+	if err != nil || strings.Join(lines, "") != "a\nb\n" {
+		t.Errorf("Lines() = %q, %v after gc", lines, err)
+	}
+	if again, err := Collect(root, false); err != nil || len(again.Removed) != 0 {
+		t.Errorf("second Collect() = %+v, %v; want nothing removed", again, err)
+	}
+}
@@ -0,0 +1,83 @@
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	cp "github.com/mainak55512/qwe/compressor"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// Files modified this recently are not recorded, a later change within the resolution
+// of the file system clock could leave size and modification time as they were
+const racyWindow = 2 * time.Second
+
+// State of a tracked file on disk when it was last found equal to a version
+type Entry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"` // in nanoseconds since the epoch
+	Hash    string `json:"hash"`     // content hash of the file, see utl.ContentID
+	Version string `json:"version"`  // version the file was equal to, Base or a version UID
+}
+
+// Stat cache of the tracked files stored in _index.qwe, keyed by file id.
+// It only speeds up change detection, a missing or unreadable index is an empty one.
+type Index map[string]Entry
+
+func indexPath(root string) string {
+	return utl.QwePath(root, "_index.qwe")
+}
+
+// Returns the index of the repository at root
+func Load(root string) Index {
+	ix := make(Index)
+	content, err := cp.ReadFile(indexPath(root))
+	if err != nil || json.Unmarshal(content, &ix) != nil {
+		return make(Index)
+	}
+	return ix
+}
+
+// Writes the index of the repository at root
+func (ix Index) Save(root string) error {
+	marshalContent, err := json.Marshal(ix)
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	return cp.WriteFile(indexPath(root), marshalContent)
+}
+
+// Returns the recorded content hash of the file if it is still equal to the version,
+// judged by its size and modification time. Reports false if that is not known.
+func (ix Index) Unchanged(fileId, workPath, version string) (string, bool) {
+	entry, ok := ix[fileId]
+	if !ok || entry.Version != version {
+		return "", false
+	}
+	info, err := os.Stat(workPath)
+	if err != nil || info.Size() != entry.Size || info.ModTime().UnixNano() != entry.ModTime {
+		return "", false
+	}
+	return entry.Hash, true
+}
+
+// Records the state of a file a command has just rewritten to the version, see Update.
+// Commands rewriting files call it so that the entry of the previous content does not linger.
+func Record(root, fileId, workPath, version, hash string) error {
+	ix := Load(root)
+	ix.Update(fileId, workPath, version, hash)
+	return ix.Save(root)
+}
+
+// Records that the file on disk is equal to the version and has the content hash.
+// Files that do not exist or were modified too recently to be recognized later are dropped from the index.
+func (ix Index) Update(fileId, workPath, version, hash string) {
+	info, err := os.Stat(workPath)
+	if err != nil || version == "" || hash == "" || time.Since(info.ModTime()) < racyWindow {
+		delete(ix, fileId)
+		return
+	}
+	ix[fileId] = Entry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: hash, Version: version}
+}
@@ -0,0 +1,61 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// TestIndex tests when the index vouches for an unchanged file
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(utl.QwePath(root), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files modified just now are not recorded
+	ix := Load(root)
+	ix.Update("id", file, "v1", "hash")
+	if _, ok := ix.Unchanged("id", file, "v1"); ok {
+		t.Fatal("recently modified file was recorded")
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(file, past, past); err != nil {
+		t.Fatal(err)
+	}
+	ix.Update("id", file, "v1", "hash")
+	if err := ix.Save(root); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	ix = Load(root)
+	if hash, ok := ix.Unchanged("id", file, "v1"); !ok || hash != "hash" {
+		t.Errorf("Unchanged() = %q, %v; want hash, true", hash, ok)
+	}
+	if _, ok := ix.Unchanged("id", file, "v2"); ok {
+		t.Errorf("Unchanged() vouched for another version")
+	}
+
+	// A change of the same size is noticed by the modification time
+	if err := os.WriteFile(file, []byte("b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ix.Unchanged("id", file, "v1"); ok {
+		t.Errorf("Unchanged() missed a modified file")
+	}
+
+	// An unreadable index is an empty one
+	if err := os.WriteFile(utl.QwePath(root, "_index.qwe"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ix := Load(root); len(ix) != 0 {
+		t.Errorf("Load() = %v, want an empty index", ix)
+	}
+}
@@ -10,9 +10,9 @@ import (
 	"time"
 )
 
-// Initiates qwe repository
-func Init() error {
-	qwePath := ".qwe"
+// Initiates qwe repository at root
+func Init(root string) error {
+	qwePath := utl.QwePath(root)
 
 	// Check if qwe is already initialized
 	if exists := utl.FolderExists(qwePath); exists {
@@ -20,43 +20,46 @@ func Init() error {
 	} else {
 
 		// Create objects directory
-		if err := os.MkdirAll(qwePath+"/_object/", os.ModePerm); err != nil {
+		if err := os.MkdirAll(utl.QwePath(root, "_object"), os.ModePerm); err != nil {
 			return er.RepoInitError
 		}
 		// Create _tracker.qwe file
-		if _, err := os.Create(qwePath + "/_tracker.qwe"); err != nil {
+		if _, err := os.Create(utl.QwePath(root, "_tracker.qwe")); err != nil {
 			os.RemoveAll(qwePath)
 			return er.RepoInitError
 		}
 		// Create _group_tracker.qwe file
-		if _, err := os.Create(qwePath + "/_group_tracker.qwe"); err != nil {
+		if _, err := os.Create(utl.QwePath(root, "_group_tracker.qwe")); err != nil {
 			os.RemoveAll(qwePath)
 			return er.RepoInitError
 		}
 		// Initialize the tracker with '{}'
-		if err := tr.SaveTracker(0, []byte("{}")); err != nil {
+		if err := tr.SaveTracker(root, 0, []byte("{}")); err != nil {
 			return err
 		}
 		// Initialize the group tracker with '{}'
-		if err := tr.SaveTracker(1, []byte("{}")); err != nil {
+		if err := tr.SaveTracker(root, 1, []byte("{}")); err != nil {
+			return err
+		}
+		// Record the schema version of the repository layout
+		if err := tr.SaveMeta(root, tr.Meta{SchemaVersion: tr.SchemaVersion}); err != nil {
 			return err
 		}
 	}
-	fmt.Println("QWE initiated")
 	return nil
 }
 
 // Initiate a group in a qwe repository
-func GroupInit(groupName string) error {
+func GroupInit(root, groupName string) error {
 
-	qwePath := ".qwe"
+	qwePath := utl.QwePath(root)
 
 	if exists := utl.FolderExists(qwePath); !exists {
 		return er.RepoNotFound
 	}
 
 	// Get group tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(root, 1)
 	if err != nil {
 		return err
 	}
@@ -88,9 +91,8 @@ func GroupInit(groupName string) error {
 	}
 
 	// Update the tracker
-	if err = tr.SaveTracker(1, marshalContent); err != nil {
+	if err = tr.SaveTracker(root, 1, marshalContent); err != nil {
 		return err
 	}
-	fmt.Println("Started tracking group ", groupName)
 	return nil
 }
@@ -48,7 +48,7 @@ func TestInit_SuccessfulInitialization(t *testing.T) {
 	tempDir, cleanup := setupTestDir(t)
 	defer cleanup()
 
-	err := Init()
+	err := Init(".")
 	if err != nil {
 		t.Fatalf("Init() failed: %v", err)
 	}
@@ -78,7 +78,7 @@ func TestInit_SuccessfulInitialization(t *testing.T) {
 		t.Error(".qwe/_group_tracker.qwe file was not created")
 	}
 
-	_, _, err = tr.GetTracker(0)
+	_, _, err = tr.GetTracker(".", 0)
 
 	if err != nil {
 		t.Fatalf("failed to read tracker file: %v", err)
@@ -91,13 +91,13 @@ func TestInit_RepoAlreadyInitialized(t *testing.T) {
 	defer cleanup()
 
 	// First initialization should succeed
-	err := Init()
+	err := Init(".")
 	if err != nil {
 		t.Fatalf("first Init() call failed: %v", err)
 	}
 
 	// Second initialization should fail with RepoAlreadyInit error
-	err = Init()
+	err = Init(".")
 	if err == nil {
 		t.Fatal("expected error when initializing already initialized repository, got nil")
 	}
@@ -116,19 +116,19 @@ func TestGroupInit_SuccessfulInitialization(t *testing.T) {
 	defer cleanup()
 
 	// Initialize qwe repository first
-	if err := Init(); err != nil {
+	if err := Init("."); err != nil {
 		t.Fatalf("Init() failed: %v", err)
 	}
 
 	// Initialize a group
 	groupName := "test-group"
-	err := GroupInit(groupName)
+	err := GroupInit(".", groupName)
 	if err != nil {
 		t.Fatalf("GroupInit() failed: %v", err)
 	}
 
 	// Verify group was added to group tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(".", 1)
 	if err != nil {
 		t.Fatalf("failed to get group tracker: %v", err)
 	}
@@ -173,7 +173,7 @@ func TestGroupInit_RepoNotInitialized(t *testing.T) {
 	defer cleanup()
 
 	// Try to initialize group without initializing repository first
-	err := GroupInit("test-group")
+	err := GroupInit(".", "test-group")
 	if err == nil {
 		t.Fatal("expected error when initializing group without repository, got nil")
 	}
@@ -191,19 +191,19 @@ func TestGroupInit_GroupAlreadyTracked(t *testing.T) {
 	defer cleanup()
 
 	// Initialize qwe repository
-	if err := Init(); err != nil {
+	if err := Init("."); err != nil {
 		t.Fatalf("Init() failed: %v", err)
 	}
 
 	groupName := "test-group"
 
 	// First group initialization should succeed
-	if err := GroupInit(groupName); err != nil {
+	if err := GroupInit(".", groupName); err != nil {
 		t.Fatalf("first GroupInit() failed: %v", err)
 	}
 
 	// Second initialization of same group should fail
-	err := GroupInit(groupName)
+	err := GroupInit(".", groupName)
 	if err == nil {
 		t.Fatal("expected error when initializing already tracked group, got nil")
 	}
@@ -222,20 +222,20 @@ func TestGroupInit_MultipleGroups(t *testing.T) {
 	defer cleanup()
 
 	// Initialize qwe repository
-	if err := Init(); err != nil {
+	if err := Init("."); err != nil {
 		t.Fatalf("Init() failed: %v", err)
 	}
 
 	// Initialize multiple groups
 	groups := []string{"group1", "group2", "group3"}
 	for _, groupName := range groups {
-		if err := GroupInit(groupName); err != nil {
+		if err := GroupInit(".", groupName); err != nil {
 			t.Fatalf("GroupInit(%s) failed: %v", groupName, err)
 		}
 	}
 
 	// Verify all groups exist in tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(".", 1)
 	if err != nil {
 		t.Fatalf("failed to get group tracker: %v", err)
 	}
@@ -265,7 +265,7 @@ func TestGroupInit_ValidatesGroupName(t *testing.T) {
 	defer cleanup()
 
 	// Initialize qwe repository
-	if err := Init(); err != nil {
+	if err := Init("."); err != nil {
 		t.Fatalf("Init() failed: %v", err)
 	}
 
@@ -298,7 +298,7 @@ func TestGroupInit_ValidatesGroupName(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := GroupInit(tt.groupName)
+			err := GroupInit(".", tt.groupName)
 			if tt.shouldErr && err == nil {
 				t.Error("expected error, got nil")
 			}
@@ -0,0 +1,134 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// Time a command waits for another qwe process to release the repository
+const DefaultTimeout = 10 * time.Second
+
+// Interval at which a held lock is checked again
+const retryInterval = 50 * time.Millisecond
+
+// Age below which a lock file without a PID is taken for one still being written, see create
+const writeGrace = time.Second
+
+// Creates a hard link, replaced in tests to simulate file systems without hard links
+var hardLink = os.Link
+
+// Advisory lock on a repository, held by creating .qwe/_lock containing the PID of the holder
+type Lock struct {
+	path string
+}
+
+// Returns the path of the lock file of the repository at root
+func lockPath(root string) string {
+	return utl.QwePath(root, "_lock")
+}
+
+// Acquires the lock of the repository at root, waiting up to timeout for another process to release it.
+// A lock whose holding process is gone is considered stale and taken over.
+func Acquire(root string, timeout time.Duration) (*Lock, error) {
+	path := lockPath(root)
+	deadline := time.Now().Add(timeout)
+	for {
+		err := create(path)
+		if err == nil {
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		pid, content, ok := holder(path)
+		if ok && !processAlive(pid) || !ok && !recent(path) {
+			removeStale(path, content)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: held by process %d, remove %s if that process is not qwe", er.RepoLocked, pid, path)
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// Releases the lock
+func (l *Lock) Release() error {
+	return os.Remove(l.path)
+}
+
+// Creates the lock file with the PID of this process.
+// The PID is written to a temporary file first and hard linked to the lock file,
+// so the lock file never exists without its content where hard links are supported.
+func create(path string) error {
+	tmpPath := fmt.Sprintf("%s.%d", utl.TempPath(path), os.Getpid())
+	if err := os.WriteFile(tmpPath, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	return link(tmpPath, path)
+}
+
+// Links oldPath to newPath, failing with os.ErrExist if newPath exists.
+// On file systems without hard links newPath is created exclusively and the content copied,
+// it is empty for a moment then.
+func link(oldPath, newPath string) error {
+	err := hardLink(oldPath, newPath)
+	if err == nil || errors.Is(err, os.ErrExist) {
+		return err
+	}
+	content, err := os.ReadFile(oldPath)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(newPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Reports whether the lock file was created so recently that its PID may still be being written
+func recent(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) < writeGrace
+}
+
+// Returns the PID stored in the lock file and the raw content, ok is false if the lock file is unreadable
+func holder(path string) (int, string, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return 0, string(content), false
+	}
+	return pid, string(content), true
+}
+
+// Removes a stale lock file unless another process has taken the lock over in the meantime.
+// The lock file is renamed to a name of its own first, so the content checked is the one removed;
+// a lock taken over between reading the holder and renaming is put back.
+func removeStale(path, content string) {
+	stalePath := fmt.Sprintf("%s.stale.%d.%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, stalePath); err != nil {
+		return
+	}
+	if current, err := os.ReadFile(stalePath); err == nil && string(current) != content {
+		link(stalePath, path)
+	}
+	os.Remove(stalePath)
+}
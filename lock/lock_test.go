@@ -0,0 +1,111 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(utl.QwePath(root), 0755); err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	return root
+}
+
+// TestAcquire_Timeout tests that a held lock makes other acquirers fail after the timeout
+func TestAcquire_Timeout(t *testing.T) {
+	root := newRepo(t)
+	l, err := Acquire(root, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := Acquire(root, 200*time.Millisecond); !errors.Is(err, er.RepoLocked) {
+		t.Errorf("expected RepoLocked error, got: %v", err)
+	}
+	if time.Since(start) < 200*time.Millisecond {
+		t.Errorf("Acquire() gave up before the timeout")
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+	l, err = Acquire(root, 0)
+	if err != nil {
+		t.Fatalf("Acquire() after Release() failed: %v", err)
+	}
+	l.Release()
+}
+
+// TestAcquire_StaleLock tests that a lock left by a process that is gone is taken over
+func TestAcquire_StaleLock(t *testing.T) {
+	root := newRepo(t)
+
+	// PID of a process that has already exited
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run helper process: %v", err)
+	}
+	if err := os.WriteFile(lockPath(root), []byte(strconv.Itoa(cmd.Process.Pid)), 0644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+
+	l, err := Acquire(root, 0)
+	if err != nil {
+		t.Fatalf("Acquire() did not take over the stale lock: %v", err)
+	}
+	defer l.Release()
+	if pid, _, ok := holder(lockPath(root)); !ok || pid != os.Getpid() {
+		t.Errorf("expected lock to be held by %d, got %d", os.Getpid(), pid)
+	}
+}
+
+// TestRemoveStale_TakenOver tests that a lock taken over by another process after it was found stale is kept
+func TestRemoveStale_TakenOver(t *testing.T) {
+	root := newRepo(t)
+	if err := os.WriteFile(lockPath(root), []byte("12345"), 0644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+	removeStale(lockPath(root), "999")
+	if content, err := os.ReadFile(lockPath(root)); err != nil || string(content) != "12345" {
+		t.Errorf("lock file = %q, %v; want the lock of the other process", content, err)
+	}
+	removeStale(lockPath(root), "12345")
+	if utl.FileExists(lockPath(root)) {
+		t.Errorf("stale lock file was not removed")
+	}
+	if entries, _ := os.ReadDir(utl.QwePath(root)); len(entries) != 0 {
+		t.Errorf("files left behind: %v", entries)
+	}
+}
+
+// TestAcquire_NoHardLinks tests that the lock works on file systems that do not support hard links
+func TestAcquire_NoHardLinks(t *testing.T) {
+	root := newRepo(t)
+	hardLink = func(oldPath, newPath string) error {
+		return &os.LinkError{Op: "link", Old: oldPath, New: newPath, Err: errors.ErrUnsupported}
+	}
+	defer func() { hardLink = os.Link }()
+
+	l, err := Acquire(root, 0)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	defer l.Release()
+	if pid, _, ok := holder(lockPath(root)); !ok || pid != os.Getpid() {
+		t.Errorf("expected lock to be held by %d, got %d", os.Getpid(), pid)
+	}
+	if _, err := Acquire(root, 0); !errors.Is(err, er.RepoLocked) {
+		t.Errorf("expected RepoLocked error, got: %v", err)
+	}
+}
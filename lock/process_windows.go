@@ -0,0 +1,15 @@
+//go:build windows
+
+package lock
+
+import "os"
+
+// Reports whether a process with the PID is running
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
@@ -0,0 +1,135 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	dl "github.com/mainak55512/qwe/delta"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Outcome of merging two commits of a file
+type Result struct {
+	Ancestor  int // commit id of the common ancestor, -2 for the base version
+	Conflicts int // number of regions written between conflict markers
+}
+
+// Merges the changes two commits of a text file made since their common ancestor and writes the result
+// to the file, regions both commits changed differently are written between conflict markers.
+// The merged file is not committed. A file with uncommitted changes is refused unless force is set.
+func Merge(root, filePath string, commitA, commitB int, force bool) (Result, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Get tracker details
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Check if the file is tracked
+	val, ok := tracker[utl.Hasher(filePath)]
+	if !ok {
+		return Result{}, er.FileNotTracked
+	}
+	if strings.HasPrefix(val.Base, "_bin_") {
+		return Result{}, er.BinFileErr
+	}
+	for _, commitNumber := range []int{commitA, commitB} {
+		if commitNumber < 0 || commitNumber > len(val.Versions)-1 {
+			return Result{}, er.InvalidCommitNo
+		}
+	}
+
+	// Refuse to overwrite changes that exist only in the working file
+	workPath := utl.WorkPath(root, filePath)
+	if !force {
+		changed, err := modified(root, val, workPath)
+		if err != nil {
+			return Result{}, err
+		}
+		if changed {
+			return Result{}, er.UncommittedChanges
+		}
+	}
+
+	ancestor := commonAncestor(val, commitA, commitB)
+	base, err := res.Lines(root, val, ancestor)
+	if err != nil {
+		return Result{}, err
+	}
+	ours, err := res.Lines(root, val, commitA)
+	if err != nil {
+		return Result{}, err
+	}
+	theirs, err := res.Lines(root, val, commitB)
+	if err != nil {
+		return Result{}, err
+	}
+
+	merged, conflicts := dl.Merge(base, ours, theirs, fmt.Sprintf("commit %d", commitA), fmt.Sprintf("commit %d", commitB))
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(workPath); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err = utl.WriteFileAtomic(workPath, []byte(strings.Join(merged, "")), perm); err != nil {
+		return Result{}, er.OutputWriteErr
+	}
+	return Result{Ancestor: ancestor, Conflicts: conflicts}, nil
+}
+
+// Returns true if the file differs from its current version, a deleted file has no changes to lose
+func modified(root string, val tr.Tracker, workPath string) (bool, error) {
+	content, err := os.ReadFile(workPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if hash := val.ContentHash(val.Current); hash != "" {
+		return utl.ContentID(content) != hash, nil
+	}
+
+	// Versions committed by earlier versions of qwe have no recorded hash, compare with the content itself
+	commitNumber := val.CommitNumber(val.Current)
+	if commitNumber == -3 {
+		return false, er.BrokenHistory
+	}
+	lines, err := res.Lines(root, val, commitNumber)
+	if err != nil {
+		return false, err
+	}
+	return string(content) != strings.Join(lines, ""), nil
+}
+
+// Returns the latest commit both commits descend from, -2 if they only share the base version
+func commonAncestor(val tr.Tracker, commitA, commitB int) int {
+	ancestors := make(map[int]bool)
+	for commit := commitA; commit >= 0 && !ancestors[commit]; commit = val.Parent(commit) {
+		ancestors[commit] = true
+	}
+
+	// Parents are committed before their children, a history pointing elsewhere is not followed any further
+	for commit := commitB; commit >= 0; {
+		if ancestors[commit] {
+			return commit
+		}
+		parent := val.Parent(commit)
+		if parent >= commit {
+			break
+		}
+		commit = parent
+	}
+	return -2
+}
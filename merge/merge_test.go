@@ -0,0 +1,145 @@
+package merge
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mainak55512/qwe/branch"
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	er "github.com/mainak55512/qwe/qwerror"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestMerge tests that merging a commit with one of its descendants yields the descendant
+func TestMerge(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a\nb\nc\n")
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	for _, content := range []string{"a\nB\nc\n", "a\nB\nC\n"} {
+		write(content)
+		if _, _, err := cm.CommitUnit(root, "notes.txt", "update"); err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+	}
+
+	if _, err := Merge(root, "notes.txt", 0, 2, false); !errors.Is(err, er.InvalidCommitNo) {
+		t.Errorf("expected InvalidCommitNo, got %v", err)
+	}
+	result, err := Merge(root, "notes.txt", 1, 0, false)
+	if err != nil || result.Ancestor != 0 || result.Conflicts != 0 {
+		t.Fatalf("Merge() = %+v, %v; want ancestor 0 without conflicts", result, err)
+	}
+	if got, _ := os.ReadFile(notes); string(got) != "a\nB\nC\n" {
+		t.Errorf("merged content = %q; want %q", got, "a\nB\nC\n")
+	}
+}
+
+// TestMerge_Branches tests that commits of diverged branches merge from the commit the branches forked at
+func TestMerge_Branches(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	commit := func(content string) int {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, commitID, err := cm.CommitUnit(root, "notes.txt", "update")
+		if err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+		return commitID
+	}
+	if err := os.WriteFile(notes, []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	fork := commit("a\nb\nc\nd\n")
+	commit("a\nb\nc\nd\ne\n")
+
+	if err := branch.Create(root, "notes.txt", "exp", fork); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if _, err := branch.Switch(root, "notes.txt", "exp"); err != nil {
+		t.Fatalf("Switch() failed: %v", err)
+	}
+	ours := commit("A\nb\nc\nd\n")
+	if _, err := branch.Switch(root, "notes.txt", tr.DefaultBranch); err != nil {
+		t.Fatalf("Switch() failed: %v", err)
+	}
+	theirs := commit("a\nb\nC\nd\ne\n")
+
+	result, err := Merge(root, "notes.txt", ours, theirs, false)
+	if err != nil || result.Ancestor != fork || result.Conflicts != 0 {
+		t.Fatalf("Merge() = %+v, %v; want ancestor %d without conflicts", result, err, fork)
+	}
+	if got, _ := os.ReadFile(notes); string(got) != "A\nb\nC\nd\ne\n" {
+		t.Errorf("merged content = %q; want %q", got, "A\nb\nC\nd\ne\n")
+	}
+}
+
+// TestMerge_Uncommitted tests that merging over uncommitted changes is refused unless forced
+func TestMerge_Uncommitted(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := os.WriteFile(notes, []byte("a\nb\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	for _, content := range []string{"a\nB\n", "A\nB\n"} {
+		if err := os.WriteFile(notes, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := cm.CommitUnit(root, "notes.txt", "update"); err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(notes, []byte("uncommitted\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Merge(root, "notes.txt", 0, 1, false); !errors.Is(err, er.UncommittedChanges) {
+		t.Errorf("expected UncommittedChanges, got %v", err)
+	}
+	if got, _ := os.ReadFile(notes); string(got) != "uncommitted\n" {
+		t.Errorf("refused merge changed the file to %q", got)
+	}
+
+	if _, err := Merge(root, "notes.txt", 0, 1, true); err != nil {
+		t.Fatalf("Merge() with force failed: %v", err)
+	}
+	if got, _ := os.ReadFile(notes); string(got) != "A\nB\n" {
+		t.Errorf("merged content = %q; want %q", got, "A\nB\n")
+	}
+	info, err := os.Stat(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("merged file mode = %v; want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+}
@@ -0,0 +1,96 @@
+package migrate
+
+import (
+	er "github.com/mainak55512/qwe/qwerror"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Upgrade of the repository layout from one schema version to the next one
+type step struct {
+	from        int
+	description string
+	run         func(root string) error
+}
+
+// Steps in the order of the schema versions they upgrade from
+var steps = []step{
+	{
+		from:        1,
+		description: "tracked file paths are stored in their canonical form",
+		run:         canonicalizePaths,
+	},
+	{
+		from:        2,
+		description: "objects are named after their content and stored once",
+		run:         contentAddressObjects,
+	},
+	{
+		from:        3,
+		description: "tracked files record their path",
+		run:         recordPaths,
+	},
+	{
+		// Nothing to convert, the version only keeps earlier versions of qwe from reading deltas as full copies
+		from:        4,
+		description: "binary versions may be stored as deltas",
+		run:         func(root string) error { return nil },
+	},
+	{
+		// Nothing to convert either, earlier versions of qwe would drop the branches when saving the trackers
+		from:        5,
+		description: "files and groups may have branches",
+		run:         func(root string) error { return nil },
+	},
+	{
+		from:        6,
+		description: "versions record the version they were committed on top of",
+		run:         recordParents,
+	},
+	{
+		// Nothing to convert, earlier versions of qwe would drop the tags when saving the metadata
+		from:        7,
+		description: "versions and group commits may be tagged",
+		run:         func(root string) error { return nil },
+	},
+}
+
+// Returns true if the repository at root was created by an older version of qwe
+func Pending(root string) (bool, error) {
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return false, err
+	}
+	if meta.SchemaVersion > tr.SchemaVersion {
+		return false, er.SchemaUnsupported
+	}
+	return meta.SchemaVersion < tr.SchemaVersion, nil
+}
+
+// Upgrades the repository at root to the latest schema version, returns the descriptions of the applied steps
+func Migrate(root string) ([]string, error) {
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return nil, err
+	}
+	if meta.SchemaVersion > tr.SchemaVersion {
+		return nil, er.SchemaUnsupported
+	}
+
+	var applied []string
+	for _, st := range steps {
+		if meta.SchemaVersion != st.from {
+			continue
+		}
+		if err := st.run(root); err != nil {
+			return applied, err
+		}
+
+		// Record the progress after every step so that a failed migration resumes from there
+		meta.SchemaVersion = st.from + 1
+		if err := tr.SaveMeta(root, meta); err != nil {
+			return applied, err
+		}
+		applied = append(applied, st.description)
+	}
+	return applied, nil
+}
@@ -0,0 +1,178 @@
+package migrate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// saveTracker overwrites _tracker.qwe of the repository
+func saveTracker(t *testing.T, root string, tracker tr.TrackerSchema) {
+	t.Helper()
+	content, err := json.Marshal(tracker)
+	if err != nil {
+		t.Fatalf("failed to marshal tracker: %v", err)
+	}
+	if err := tr.SaveTracker(root, 0, content); err != nil {
+		t.Fatalf("failed to save tracker: %v", err)
+	}
+}
+
+// rekey moves a tracker entry to the key of another spelling of its path, as older versions of qwe did
+func rekey(t *testing.T, root, from, to string, timeStamp string) {
+	t.Helper()
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		t.Fatalf("failed to get tracker: %v", err)
+	}
+	entry := tracker[utl.Hasher(from)]
+	for i := range entry.Versions {
+		entry.Versions[i].TimeStamp = timeStamp
+	}
+	delete(tracker, utl.Hasher(from))
+	tracker[utl.Hasher(to)] = entry
+	saveTracker(t, root, tracker)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// TestMigrate_MergesDuplicateEntries tests that entries of './notes.txt' and 'notes.txt' become one history
+func TestMigrate_MergesDuplicateEntries(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+
+	// First entry, tracked as './notes.txt'
+	writeFile(t, notes, "v0\n")
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	writeFile(t, notes, "v1\n")
+	if _, _, err := cm.CommitUnit(root, "notes.txt", "first"); err != nil {
+		t.Fatalf("CommitUnit() failed: %v", err)
+	}
+	rekey(t, root, "notes.txt", "./notes.txt", "2025-01-01 10:00")
+
+	// Second entry, tracked as 'notes.txt' in a group
+	writeFile(t, notes, "v2\n")
+	if err := in.GroupInit(root, "docs"); err != nil {
+		t.Fatalf("GroupInit() failed: %v", err)
+	}
+	if _, err := tr.StartGroupTracking(root, "docs", []string{"notes.txt"}, false); err != nil {
+		t.Fatalf("StartGroupTracking() failed: %v", err)
+	}
+	writeFile(t, notes, "v3\n")
+	if _, _, err := cm.CommitGroup(root, "docs", "second"); err != nil {
+		t.Fatalf("CommitGroup() failed: %v", err)
+	}
+	rekey(t, root, "notes.txt", "notes.txt", "2025-01-02 10:00")
+
+	if err := tr.SaveMeta(root, tr.Meta{SchemaVersion: 1}); err != nil {
+		t.Fatalf("SaveMeta() failed: %v", err)
+	}
+
+	applied, err := Migrate(root)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	if len(applied) != len(steps) {
+		t.Errorf("expected %d applied steps, got %d", len(steps), len(applied))
+	}
+
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		t.Fatalf("failed to get tracker: %v", err)
+	}
+	if len(tracker) != 1 {
+		t.Fatalf("expected a single tracker entry, got %d", len(tracker))
+	}
+	entry, ok := tracker[utl.Hasher("notes.txt")]
+	if !ok {
+		t.Fatal("merged entry is not keyed by the canonical path")
+	}
+	if entry.Path != "notes.txt" {
+		t.Errorf("expected the entry to record its path, got %q", entry.Path)
+	}
+
+	want := []string{"v1\n", "v2\n", "v3\n"}
+	if len(entry.Versions) != len(want) {
+		t.Fatalf("expected %d versions, got %d", len(want), len(entry.Versions))
+	}
+	for i, content := range want {
+		lines, err := res.Lines(root, entry, i)
+		if err != nil {
+			t.Fatalf("Lines(%d) failed: %v", i, err)
+		}
+		if got := strings.Join(lines, ""); got != content {
+			t.Errorf("version %d: expected %q, got %q", i, content, got)
+		}
+	}
+	if entry.Current != entry.Versions[2].UID {
+		t.Errorf("expected current version to be the latest commit")
+	}
+
+	// The merged history is linear
+	for i, version := range entry.Versions {
+		wantParent := entry.Base
+		if i > 0 {
+			wantParent = entry.Versions[i-1].UID
+		}
+		if version.Parent != wantParent {
+			t.Errorf("version %d: expected parent %q, got %q", i, wantParent, version.Parent)
+		}
+	}
+
+	// Group commits follow the merged history
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		t.Fatalf("failed to get group tracker: %v", err)
+	}
+	gr := groupTracker[utl.Hasher("docs")]
+	first := gr.Versions[gr.VersionOrder[0]].Files[utl.Hasher("notes.txt")]
+	if first.CommitNumber != 1 || first.FileObjID != entry.Versions[1].UID {
+		t.Errorf("first group commit refers to %d %s, want 1 %s", first.CommitNumber, first.FileObjID, entry.Versions[1].UID)
+	}
+	latest := gr.Versions[gr.Current].Files[utl.Hasher("notes.txt")]
+	if latest.CommitNumber != 2 || latest.FileObjID != entry.Versions[2].UID {
+		t.Errorf("latest group commit refers to %d %s, want 2 %s", latest.CommitNumber, latest.FileObjID, entry.Versions[2].UID)
+	}
+
+	// Every object is content addressed and no object is left in the old layout
+	if !utl.IsContentID(entry.Base) {
+		t.Errorf("base object %s is not content addressed", entry.Base)
+	}
+	for _, version := range entry.Versions {
+		if !utl.IsContentID(version.ObjID) {
+			t.Errorf("version object %s is not content addressed", version.ObjID)
+		}
+	}
+	objects, err := os.ReadDir(utl.QwePath(root, "_object"))
+	if err != nil {
+		t.Fatalf("failed to list objects: %v", err)
+	}
+	for _, object := range objects {
+		if !object.IsDir() {
+			t.Errorf("object %s was not moved to a fan-out folder", object.Name())
+		}
+	}
+
+	// Migration only runs once
+	if pending, err := Pending(root); err != nil || pending {
+		t.Errorf("Pending() = %v, %v; want false, nil", pending, err)
+	}
+}
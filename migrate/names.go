@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path/filepath"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Stores the canonical path of every tracker entry in the entry itself.
+// Entries are keyed by the hash of the path only, hence the paths are recovered from the group trackers,
+// the moves of the entries and the files present in the repository; entries of files that were deleted
+// without ever being part of a group are left without a path.
+func recordPaths(root string) error {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return err
+	}
+
+	addCandidate := func(filePath string) {
+		id := utl.Hasher(filePath)
+		if val, ok := tracker[id]; ok && val.Path == "" {
+			val.Path = filePath
+			tracker[id] = val
+		}
+	}
+	for _, val := range tracker {
+		if len(val.Renames) > 0 {
+			addCandidate(val.Renames[len(val.Renames)-1].To)
+		}
+	}
+	for _, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			for _, file := range version.Files {
+				addCandidate(file.FileName)
+			}
+		}
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	err = filepath.WalkDir(absRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if entry.Name() == utl.QweDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(absRoot, path); err == nil {
+			addCandidate(filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	return tr.SaveTracker(root, 0, marshalContent)
+}
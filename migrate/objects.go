@@ -0,0 +1,122 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	ob "github.com/mainak55512/qwe/object"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Stores every object under the hash of its content, identical objects are kept once.
+// Versions keep their UID and reference their object through ObjID, base objects are referenced by their new ID.
+// Objects that are missing are left referenced by their old ID.
+func contentAddressObjects(root string) error {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return err
+	}
+
+	// Old object ID to new object ID, and the same for base objects only
+	renamed := make(map[string]string)
+	bases := make(map[string]string)
+	store := func(objID, prefix string) (string, error) {
+		if utl.IsContentID(objID) {
+			return objID, nil
+		}
+		if newID, ok := renamed[objID]; ok {
+			return newID, nil
+		}
+		content, err := res.ReadObject(root, objID)
+		if errors.Is(err, fs.ErrNotExist) {
+			return objID, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		newID, err := ob.Write(root, prefix, content)
+		if err != nil {
+			return "", err
+		}
+		renamed[objID] = newID
+		return newID, nil
+	}
+
+	for fileID, val := range tracker {
+		basePrefix, versionPrefix := "_base_", ""
+		if strings.HasPrefix(val.Base, "_bin_") {
+			basePrefix, versionPrefix = "_bin_", "_bin_"
+		}
+
+		base, err := store(val.Base, basePrefix)
+		if err != nil {
+			return err
+		}
+		if val.Current == val.Base {
+			val.Current = base
+		}
+		bases[val.Base] = base
+		val.Base = base
+
+		for i, version := range val.Versions {
+			if version.ObjID, err = store(version.Object(), versionPrefix); err != nil {
+				return err
+			}
+			val.Versions[i] = version
+		}
+		tracker[fileID] = val
+	}
+
+	// Groups reference a file at its base version by the base object, other versions keep their UID
+	for _, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			for fileID, file := range version.Files {
+				if newID, ok := bases[file.FileObjID]; ok {
+					file.FileObjID = newID
+					version.Files[fileID] = file
+				}
+
+				// Binary files unchanged in a group commit were recorded without their version
+				if val, ok := tracker[fileID]; ok && file.FileObjID == "" {
+					if file.CommitNumber == -2 {
+						file.FileObjID = val.Base
+					} else if file.CommitNumber >= 0 && file.CommitNumber < len(val.Versions) {
+						file.FileObjID = val.Versions[file.CommitNumber].UID
+					}
+					version.Files[fileID] = file
+				}
+			}
+		}
+	}
+
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 0, marshalContent); err != nil {
+		return err
+	}
+	marshalContent, err = json.MarshalIndent(groupTracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 1, marshalContent); err != nil {
+		return err
+	}
+
+	// The objects are only removed once no tracker references them anymore
+	for objID := range renamed {
+		os.Remove(utl.ObjectPath(root, objID))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"encoding/json"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Records the parent of every file version and group commit. Histories were linear so far,
+// every version was committed on top of the previous one and the first one on top of the base version.
+func recordParents(root string) error {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return err
+	}
+
+	for fileId, val := range tracker {
+		for i := range val.Versions {
+			if val.Versions[i].Parent != "" {
+				continue
+			}
+			val.Versions[i].Parent = val.Base
+			if i > 0 {
+				val.Versions[i].Parent = val.Versions[i-1].UID
+			}
+		}
+		tracker[fileId] = val
+	}
+	for groupId, gr := range groupTracker {
+		for i := 1; i < len(gr.VersionOrder); i++ {
+			if version, ok := gr.Versions[gr.VersionOrder[i]]; ok && version.Parent == "" {
+				version.Parent = gr.VersionOrder[i-1]
+				gr.Versions[gr.VersionOrder[i]] = version
+			}
+		}
+		groupTracker[groupId] = gr
+	}
+
+	// The file tracker goes first as the groups refer to its versions
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 0, marshalContent); err != nil {
+		return err
+	}
+	if marshalContent, err = json.MarshalIndent(groupTracker, "", " "); err != nil {
+		return er.TrackerWriteErr
+	}
+	return tr.SaveTracker(root, 1, marshalContent)
+}
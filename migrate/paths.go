@@ -0,0 +1,359 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cp "github.com/mainak55512/qwe/compressor"
+	dl "github.com/mainak55512/qwe/delta"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// New location of a version after its tracker entry has been merged
+type versionRef struct {
+	uid          string
+	commitNumber int
+}
+
+// Re-keys tracker entries by the canonical path of their file and merges entries
+// that were created for different spellings of the same path, e.g. 'notes.txt' and './notes.txt'.
+// Trackers only store the hash of the path, hence the spellings are recovered from the group
+// trackers and the files present in the repository; entries that can not be matched are left as they are.
+func canonicalizePaths(root string) error {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return err
+	}
+
+	// Canonical path of every tracker entry whose spelling is recovered
+	names := make(map[string]string)
+	addCandidate := func(spelling string) {
+		canonical, err := utl.Canonical(root, spelling)
+		if err != nil {
+			return
+		}
+		for _, id := range []string{utl.Hasher(spelling), utl.Hasher(canonical)} {
+			if _, ok := tracker[id]; ok {
+				names[id] = canonical
+			}
+		}
+	}
+
+	for _, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			for _, file := range version.Files {
+				addCandidate(file.FileName)
+			}
+		}
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	resolvedRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		resolvedRoot = absRoot
+	}
+	err = filepath.Walk(absRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == utl.QweDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(absRoot, path)
+		if err != nil {
+			return nil
+		}
+
+		// Spellings the file could have been tracked with from the repository root
+		for _, spelling := range []string{
+			filepath.ToSlash(rel),
+			"./" + filepath.ToSlash(rel),
+			rel,
+			"." + string(filepath.Separator) + rel,
+			path,
+			filepath.Join(resolvedRoot, rel),
+		} {
+			addCandidate(spelling)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Group the tracker entries by canonical path
+	byPath := make(map[string][]string)
+	for id, canonical := range names {
+		byPath[canonical] = append(byPath[canonical], id)
+	}
+
+	refs := make(map[string]versionRef)
+	var obsolete []string
+	for canonical, ids := range byPath {
+		sort.Strings(ids)
+		canonicalID := utl.Hasher(canonical)
+
+		if len(ids) == 1 {
+			if ids[0] != canonicalID {
+				tracker[canonicalID] = tracker[ids[0]]
+				delete(tracker, ids[0])
+			}
+			continue
+		}
+
+		entries := make([]tr.Tracker, len(ids))
+		for i, id := range ids {
+			entries[i] = tracker[id]
+		}
+		merged, mergedRefs, unused, err := mergeEntries(root, canonical, entries)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			delete(tracker, id)
+		}
+		tracker[canonicalID] = merged
+		for uid, ref := range mergedRefs {
+			refs[uid] = ref
+		}
+		obsolete = append(obsolete, unused...)
+	}
+
+	// Point the group trackers to the canonical paths and to the merged versions
+	for groupID, gr := range groupTracker {
+		for versionID, version := range gr.Versions {
+			files := make(map[string]tr.FileDetails)
+			for _, file := range version.Files {
+				if canonical, err := utl.Canonical(root, file.FileName); err == nil {
+					file.FileName = canonical
+				}
+				if ref, ok := refs[file.FileObjID]; ok {
+					file.FileObjID = ref.uid
+					file.CommitNumber = ref.commitNumber
+				}
+				fileID := utl.Hasher(file.FileName)
+				if existing, ok := files[fileID]; ok && existing.CommitNumber >= file.CommitNumber {
+					continue
+				}
+				files[fileID] = file
+			}
+			version.Files = files
+			gr.Versions[versionID] = version
+		}
+		groupTracker[groupID] = gr
+	}
+
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 0, marshalContent); err != nil {
+		return err
+	}
+	marshalContent, err = json.MarshalIndent(groupTracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 1, marshalContent); err != nil {
+		return err
+	}
+
+	// Objects of the merged entries are no longer referenced
+	for _, objID := range obsolete {
+		os.Remove(utl.ObjectPath(root, objID))
+	}
+	return nil
+}
+
+// Version of a tracker entry replayed into the merged history
+type mergeItem struct {
+	timeStamp     string
+	commitMessage string
+	oldUID        string
+	content       []byte
+}
+
+// Returns the time stamp of the latest commit of the entry, empty if never committed
+func lastTimeStamp(entry tr.Tracker) string {
+	if len(entry.Versions) == 0 {
+		return ""
+	}
+	return entry.Versions[len(entry.Versions)-1].TimeStamp
+}
+
+// Merges the histories of several tracker entries of the same file into one.
+// The base version of the earliest tracked entry stays the base version, the versions of all entries
+// (including the base versions of the others) are ordered by time stamp and stored again as a single chain.
+// The most recently committed entry decides the current version.
+// Returns the merged entry, the new location of every old version and the objects that are no longer used.
+func mergeEntries(root, filePath string, entries []tr.Tracker) (tr.Tracker, map[string]versionRef, []string, error) {
+	primary, latest := 0, 0
+	for i, entry := range entries {
+		if firstTimeStamp(entry) < firstTimeStamp(entries[primary]) {
+			primary = i
+		}
+		if lastTimeStamp(entry) > lastTimeStamp(entries[latest]) {
+			latest = i
+		}
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Base, "_bin_") != strings.HasPrefix(entries[primary].Base, "_bin_") {
+			return tr.Tracker{}, nil, nil, fmt.Errorf("%w: %s is tracked both as a text and a binary file", er.TrackerParseErr, filePath)
+		}
+	}
+	if strings.HasPrefix(entries[primary].Base, "_bin_") {
+		merged, refs := mergeBinEntries(entries, primary, latest)
+		return merged, refs, nil, nil
+	}
+
+	// Collect the content of every version, the primary entry first to keep its order on equal time stamps
+	order := append([]int{primary}, others(len(entries), primary)...)
+	var items []mergeItem
+	var unused []string
+	for _, idx := range order {
+		entry := entries[idx]
+		if idx != primary {
+			content, err := res.ReadObject(root, entry.Base)
+			if err != nil {
+				return tr.Tracker{}, nil, nil, err
+			}
+			items = append(items, mergeItem{
+				timeStamp:     baseTimeStamp(entry),
+				commitMessage: "Base version",
+				oldUID:        entry.Base,
+				content:       content,
+			})
+			unused = append(unused, entry.Base)
+		}
+		for i, version := range entry.Versions {
+			lines, err := res.Lines(root, entry, i)
+			if err != nil {
+				return tr.Tracker{}, nil, nil, err
+			}
+			items = append(items, mergeItem{
+				timeStamp:     version.TimeStamp,
+				commitMessage: version.CommitMessage,
+				oldUID:        version.UID,
+				content:       []byte(strings.Join(lines, "")),
+			})
+			unused = append(unused, version.UID)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].timeStamp < items[j].timeStamp
+	})
+
+	merged := tr.Tracker{
+		Base:     entries[primary].Base,
+		Current:  entries[primary].Base,
+		Versions: []tr.VersionDetails{},
+	}
+	refs := map[string]versionRef{
+		merged.Base: {uid: merged.Base, commitNumber: -2},
+	}
+
+	// Store every version as a delta against the previous one
+	baseContent, err := res.ReadObject(root, merged.Base)
+	if err != nil {
+		return tr.Tracker{}, nil, nil, err
+	}
+	prev := dl.SplitLines(baseContent)
+	for i, item := range items {
+		lines := dl.SplitLines(item.content)
+		fileObjectId := utl.Hasher(fmt.Sprintf("%s%d%d", filePath, time.Now().UnixNano(), i))
+		target := utl.ObjectPath(root, fileObjectId)
+		if err := cp.WriteFile(target, dl.Encode(dl.Diff(prev, lines))); err != nil {
+			return tr.Tracker{}, nil, nil, er.OutputWriteErr
+		}
+		merged.Versions = append(merged.Versions, tr.VersionDetails{
+			UID:           fileObjectId,
+			CommitMessage: item.commitMessage,
+			TimeStamp:     item.timeStamp,
+		})
+		refs[item.oldUID] = versionRef{uid: fileObjectId, commitNumber: i}
+		prev = lines
+	}
+
+	if ref, ok := refs[entries[latest].Current]; ok {
+		merged.Current = ref.uid
+	}
+	return merged, refs, unused, nil
+}
+
+// Binary versions are full copies, hence their objects are reused as they are
+func mergeBinEntries(entries []tr.Tracker, primary, latest int) (tr.Tracker, map[string]versionRef) {
+	var items []tr.VersionDetails
+	for _, idx := range append([]int{primary}, others(len(entries), primary)...) {
+		entry := entries[idx]
+		if idx != primary {
+			items = append(items, tr.VersionDetails{
+				UID:           entry.Base,
+				CommitMessage: "Base version",
+				TimeStamp:     baseTimeStamp(entry),
+			})
+		}
+		items = append(items, entry.Versions...)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].TimeStamp < items[j].TimeStamp
+	})
+
+	merged := tr.Tracker{
+		Base:     entries[primary].Base,
+		Current:  entries[latest].Current,
+		Versions: items,
+	}
+	refs := map[string]versionRef{
+		merged.Base: {uid: merged.Base, commitNumber: -2},
+	}
+	for i, version := range items {
+		refs[version.UID] = versionRef{uid: version.UID, commitNumber: i}
+	}
+	return merged, refs
+}
+
+// Base versions have no time stamp, they are placed right before the first commit of their entry.
+// Entries that were never committed are considered the most recent ones.
+func firstTimeStamp(entry tr.Tracker) string {
+	if len(entry.Versions) == 0 {
+		return "~"
+	}
+	return entry.Versions[0].TimeStamp
+}
+
+// Time stamp given to the base version of an entry when it becomes a commit of the merged history
+func baseTimeStamp(entry tr.Tracker) string {
+	if len(entry.Versions) == 0 {
+		return time.Now().String()[:16]
+	}
+	return entry.Versions[0].TimeStamp
+}
+
+// Returns the indices below n except skip
+func others(n, skip int) []int {
+	var idx []int
+	for i := 0; i < n; i++ {
+		if i != skip {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
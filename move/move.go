@@ -0,0 +1,118 @@
+package move
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Moves a tracked file and lets its history follow it: the tracker entry and the file references
+// of every group commit are re-keyed to the new path and the move is recorded in the history.
+// If the file was already moved on disk only the trackers are updated.
+func Move(root, oldPath, newPath string) error {
+
+	// Identify both files by their canonical path
+	oldPath, err := utl.Canonical(root, oldPath)
+	if err != nil {
+		return err
+	}
+	newPath, err = utl.Canonical(root, newPath)
+	if err != nil {
+		return err
+	}
+	if oldPath == newPath {
+		return er.FileExists
+	}
+
+	// Get tracker details
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return err
+	}
+
+	oldID, newID := utl.Hasher(oldPath), utl.Hasher(newPath)
+	val, ok := tracker[oldID]
+	if !ok {
+		return er.FileNotTracked
+	}
+	if _, ok := tracker[newID]; ok {
+		return er.FileTracked
+	}
+
+	// Move the file on disk unless that already happened
+	oldExists := utl.FileExists(utl.WorkPath(root, oldPath))
+	newExists := utl.FileExists(utl.WorkPath(root, newPath))
+	switch {
+	case oldExists && newExists:
+		return er.FileExists
+	case oldExists:
+		if err := os.MkdirAll(filepath.Dir(utl.WorkPath(root, newPath)), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.Rename(utl.WorkPath(root, oldPath), utl.WorkPath(root, newPath)); err != nil {
+			return err
+		}
+	case !newExists:
+		return er.InvalidFile
+	}
+
+	// Re-key the tracker entry and record the move
+	val.Renames = append(val.Renames, tr.RenameDetails{
+		From:      oldPath,
+		To:        newPath,
+		Commits:   len(val.Versions),
+		TimeStamp: time.Now().String()[:16],
+	})
+	val.Path = newPath
+	delete(tracker, oldID)
+	tracker[newID] = val
+
+	// Every group commit refers to the file by its new path
+	for groupID, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			if file, ok := version.Files[oldID]; ok {
+				file.FileName = newPath
+				delete(version.Files, oldID)
+				version.Files[newID] = file
+			}
+		}
+		groupTracker[groupID] = gr
+	}
+
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 0, marshalContent); err != nil {
+		return err
+	}
+	marshalContent, err = json.MarshalIndent(groupTracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 1, marshalContent); err != nil {
+		return err
+	}
+
+	// The tags of the file follow it as well
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return err
+	}
+	tags, ok := meta.Tags.Files[oldID]
+	if !ok {
+		return nil
+	}
+	delete(meta.Tags.Files, oldID)
+	meta.Tags.Files[newID] = tags
+	return tr.SaveMeta(root, meta)
+}
@@ -0,0 +1,91 @@
+package move
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestMove tests that history and group references follow a moved file
+func TestMove(t *testing.T) {
+	root := t.TempDir()
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	oldFile := filepath.Join(root, "config.yaml")
+	if err := os.WriteFile(oldFile, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.GroupInit(root, "conf"); err != nil {
+		t.Fatalf("GroupInit() failed: %v", err)
+	}
+	if _, err := tr.StartGroupTracking(root, "conf", []string{"config.yaml"}, false); err != nil {
+		t.Fatalf("StartGroupTracking() failed: %v", err)
+	}
+	if err := os.WriteFile(oldFile, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cm.CommitGroup(root, "conf", "bump"); err != nil {
+		t.Fatalf("CommitGroup() failed: %v", err)
+	}
+
+	if err := Move(root, "config.yaml", "conf/config.yaml"); err != nil {
+		t.Fatalf("Move() failed: %v", err)
+	}
+	if utl.FileExists(oldFile) || !utl.FileExists(filepath.Join(root, "conf", "config.yaml")) {
+		t.Fatalf("file was not moved on disk")
+	}
+
+	tracker, groupTracker, err := func() (tr.TrackerSchema, tr.GroupTrackerSchema, error) {
+		tracker, _, err := tr.GetTracker(root, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		_, groupTracker, err := tr.GetTracker(root, 1)
+		return tracker, groupTracker, err
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, ok := tracker[utl.Hasher("conf/config.yaml")]
+	if !ok || len(tracker) != 1 {
+		t.Fatalf("tracker entry was not re-keyed: %v", tracker)
+	}
+	if len(val.Renames) != 1 || val.Renames[0].From != "config.yaml" || val.Renames[0].Commits != 1 {
+		t.Errorf("unexpected rename record: %+v", val.Renames)
+	}
+	lines, err := res.Lines(root, val, -1)
+	if err != nil || strings.Join(lines, "") != "a: 2\n" {
+		t.Errorf("history lost after move: %q, %v", lines, err)
+	}
+	for _, version := range groupTracker[utl.Hasher("conf")].Versions {
+		file, ok := version.Files[utl.Hasher("conf/config.yaml")]
+		if !ok || file.FileName != "conf/config.yaml" || len(version.Files) != 1 {
+			t.Errorf("group commit not updated: %+v", version.Files)
+		}
+	}
+
+	// Moving a file that was already moved on disk only updates the trackers
+	if err := os.Rename(filepath.Join(root, "conf", "config.yaml"), filepath.Join(root, "settings.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Move(root, "conf/config.yaml", "settings.yaml"); err != nil {
+		t.Fatalf("Move() of a file moved on disk failed: %v", err)
+	}
+	renames, err := cm.GetRenameList(root, "settings.yaml")
+	if err != nil || len(renames) != 2 {
+		t.Errorf("GetRenameList() = %+v, %v; want 2 moves", renames, err)
+	}
+	if err := Move(root, "conf/config.yaml", "other.yaml"); !errors.Is(err, er.FileNotTracked) {
+		t.Errorf("expected FileNotTracked for the old name, got: %v", err)
+	}
+}
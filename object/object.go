@@ -0,0 +1,115 @@
+package object
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cp "github.com/mainak55512/qwe/compressor"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// Stores content as an object named prefix followed by the hash of the content and returns its ID.
+// Content that is already stored is not written again.
+func Write(root, prefix string, content []byte) (string, error) {
+	objID := prefix + utl.ContentID(content)
+	objPath := utl.ObjectPath(root, objID)
+	if utl.FileExists(objPath) {
+		return objID, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(objPath), os.ModePerm); err != nil {
+		return "", er.OutputWriteErr
+	}
+	if err := cp.WriteFile(objPath, content); err != nil {
+		return "", err
+	}
+	return objID, nil
+}
+
+// Stores everything read from src as an object like Write, without holding the content in memory.
+// The content is compressed into a temporary file while it is hashed, then renamed to its object path.
+func WriteFrom(root, prefix string, src io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(utl.QwePath(root, "_object"), "_incoming_*.tmp")
+	if err != nil {
+		return "", er.OutputWriteErr
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	hasher := sha256.New()
+	zw, err := cp.NewWriter(tmp)
+	if err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if _, err = io.Copy(io.MultiWriter(zw, hasher), src); err != nil {
+		err = er.BufCopyErr
+	}
+	if cerr := zw.Close(); err == nil && cerr != nil {
+		err = er.BufCopyErr
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	objID := prefix + hex.EncodeToString(hasher.Sum(nil))
+	objPath := utl.ObjectPath(root, objID)
+	if utl.FileExists(objPath) {
+		return objID, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(objPath), os.ModePerm); err != nil {
+		return "", er.OutputWriteErr
+	}
+	if err := os.Rename(tmpPath, objPath); err != nil {
+		return "", er.OutputWriteErr
+	}
+	return objID, nil
+}
+
+// File found in the object store
+type Stored struct {
+	Name string // object ID for objects
+	Path string
+	Size int64
+}
+
+// Reports whether the file is an object at its own object path,
+// as opposed to temporary files and files misplaced in the object store
+func (s Stored) IsObject(root string) bool {
+	return !strings.HasSuffix(s.Name, ".tmp") && !strings.HasPrefix(s.Name, "_diff_") && s.Path == utl.ObjectPath(root, s.Name)
+}
+
+// Lists every file in the object store of the repository at root
+func List(root string) ([]Stored, error) {
+	var stored []Stored
+	err := filepath.WalkDir(utl.QwePath(root, "_object"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		stored = append(stored, Stored{Name: entry.Name(), Path: path, Size: info.Size()})
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return stored, nil
+}
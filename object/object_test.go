@@ -0,0 +1,46 @@
+package object
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cp "github.com/mainak55512/qwe/compressor"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// TestWrite_Deduplicates tests that identical content is stored once under the hash of its content
+func TestWrite_Deduplicates(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(utl.QwePath(root, "_object"), 0755); err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	content := []byte("same content\n")
+
+	first, err := Write(root, "_base_", content)
+	if err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	second, err := WriteFrom(root, "_base_", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("WriteFrom() failed: %v", err)
+	}
+	if first != second || first != "_base_"+utl.ContentID(content) {
+		t.Errorf("expected the same content ID, got %s and %s", first, second)
+	}
+
+	// The object lives in the fan-out folder of its hash, next to no leftover temporary file
+	objects, err := filepath.Glob(utl.QwePath(root, "_object", "*", "*"))
+	if err != nil || len(objects) != 1 || objects[0] != utl.ObjectPath(root, first) {
+		t.Errorf("expected a single object at %s, got %v", utl.ObjectPath(root, first), objects)
+	}
+	if leftovers, _ := filepath.Glob(utl.QwePath(root, "_object", "*.tmp")); len(leftovers) != 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+
+	stored, err := cp.ReadFile(utl.ObjectPath(root, first))
+	if err != nil || !bytes.Equal(stored, content) {
+		t.Errorf("stored content = %q, %v; want %q", stored, err, content)
+	}
+}
@@ -0,0 +1,517 @@
+package qwe
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/mainak55512/qwe/branch"
+	cm "github.com/mainak55512/qwe/commit"
+	"github.com/mainak55512/qwe/diff"
+	"github.com/mainak55512/qwe/fsck"
+	"github.com/mainak55512/qwe/gc"
+	in "github.com/mainak55512/qwe/initializer"
+	lk "github.com/mainak55512/qwe/lock"
+	"github.com/mainak55512/qwe/merge"
+	mg "github.com/mainak55512/qwe/migrate"
+	mv "github.com/mainak55512/qwe/move"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	rb "github.com/mainak55512/qwe/rebase"
+	rc "github.com/mainak55512/qwe/recover"
+	"github.com/mainak55512/qwe/repack"
+	rv "github.com/mainak55512/qwe/revert"
+	"github.com/mainak55512/qwe/status"
+	"github.com/mainak55512/qwe/tag"
+	tr "github.com/mainak55512/qwe/tracker"
+	ut "github.com/mainak55512/qwe/untrack"
+)
+
+// Repository gives access to a qwe repository without printing anything,
+// file paths passed to its methods are resolved against the repository root
+type Repository struct {
+	root        string
+	lockTimeout time.Duration
+}
+
+// Initiates a new repository at path and opens it
+func Init(path string) (*Repository, error) {
+	if err := in.Init(path); err != nil {
+		return nil, err
+	}
+	return Open(path)
+}
+
+// Opens the repository whose root is path, path may also point at the .qwe folder itself
+func Open(path string) (*Repository, error) {
+	if filepath.Base(filepath.Clean(path)) == utl.QweDir && utl.FolderExists(path) {
+		path = filepath.Dir(filepath.Clean(path))
+	}
+	if !utl.FolderExists(utl.QwePath(path)) {
+		return nil, er.RepoNotFound
+	}
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Repository{root: root, lockTimeout: lk.DefaultTimeout}, nil
+}
+
+// Opens the nearest repository containing path, walking up its parent folders
+func Discover(path string) (*Repository, error) {
+	root, err := utl.FindRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Repository{root: root, lockTimeout: lk.DefaultTimeout}, nil
+}
+
+// Returns the root folder of the repository
+func (r *Repository) Root() string {
+	return r.root
+}
+
+// Sets how long mutating methods wait for another qwe process to release the repository lock
+func (r *Repository) SetLockTimeout(timeout time.Duration) {
+	r.lockTimeout = timeout
+}
+
+// Runs fn while holding the repository lock, every method modifying the repository goes through it
+func (r *Repository) locked(fn func() error) error {
+	l, err := lk.Acquire(r.root, r.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	return fn()
+}
+
+// Starts tracking a file
+func (r *Repository) Track(filePath string) error {
+	return r.locked(func() error {
+		_, err := tr.StartTracking(r.root, filePath)
+		return err
+	})
+}
+
+// Starts tracking the files given by paths, which may be files, folders or glob patterns,
+// and returns the files newly tracked. Folders are only walked recursively if recursive is set.
+func (r *Repository) TrackPaths(paths []string, recursive bool) ([]string, error) {
+	var trackedFiles []string
+	err := r.locked(func() (err error) {
+		trackedFiles, err = tr.StartTrackingPaths(r.root, paths, recursive)
+		return err
+	})
+	return trackedFiles, err
+}
+
+// Creates a group to track multiple files together
+func (r *Repository) GroupInit(groupName string) error {
+	return r.locked(func() error {
+		return in.GroupInit(r.root, groupName)
+	})
+}
+
+// Starts tracking files, folders or glob patterns in a group and returns the files added to it.
+// Folders are only walked recursively if recursive is set. With auto the folders and patterns
+// are remembered and new files matching them are tracked on every group commit.
+func (r *Repository) GroupTrack(groupName string, filePaths []string, recursive, auto bool) ([]string, error) {
+	var trackedFiles []string
+	err := r.locked(func() (err error) {
+		if trackedFiles, err = tr.StartGroupTracking(r.root, groupName, filePaths, recursive); err != nil || !auto {
+			return err
+		}
+		_, err = tr.AddGroupSources(r.root, groupName, filePaths, recursive)
+		return err
+	})
+	return trackedFiles, err
+}
+
+// Moves a tracked file, its history and group references follow it to the new path
+func (r *Repository) Move(oldPath, newPath string) error {
+	return r.locked(func() error {
+		return mv.Move(r.root, oldPath, newPath)
+	})
+}
+
+// Stops tracking the file and removes its history, see untrack.Untrack
+func (r *Repository) Untrack(filePath string, force bool) (gc.Result, error) {
+	var result gc.Result
+	err := r.locked(func() (err error) {
+		result, err = ut.Untrack(r.root, filePath, force)
+		return err
+	})
+	return result, err
+}
+
+// Deletes the group and its commits, its files stay tracked
+func (r *Repository) GroupDelete(groupName string) error {
+	return r.locked(func() error {
+		return ut.DeleteGroup(r.root, groupName)
+	})
+}
+
+// Removes the file from every commit of the group, the file stays tracked
+func (r *Repository) GroupUntrack(groupName, filePath string) error {
+	return r.locked(func() error {
+		return ut.UntrackFromGroup(r.root, groupName, filePath)
+	})
+}
+
+// Commits the current content of the file and returns the new commit id,
+// returns er.NoFileOrDiff if nothing changed since the last commit
+func (r *Repository) Commit(filePath, message string) (int, error) {
+	var commitID int
+	err := r.locked(func() (err error) {
+		_, commitID, err = cm.CommitUnit(r.root, filePath, message)
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+	return commitID, nil
+}
+
+// Commits the file like Commit and also returns the checked out branch as it was before the commit
+// and whether the file was at its head, both read under the same lock as the commit.
+// A commit made off the head becomes the new head of the branch, see branch.Tip.
+func (r *Repository) CommitOnBranch(filePath, message string) (int, branch.Branch, bool, error) {
+	commitID := -1
+	var tip branch.Branch
+	var atTip bool
+	err := r.locked(func() (err error) {
+		if tip, atTip, err = branch.Tip(r.root, filePath); err != nil {
+			return err
+		}
+		_, commitID, err = cm.CommitUnit(r.root, filePath, message)
+		return err
+	})
+	if err != nil {
+		return -1, tip, atTip, err
+	}
+	return commitID, tip, atTip, nil
+}
+
+// Commits every file of the group like GroupCommit and also returns the checked out branch of the group
+// as it was before the commit and whether the group was at its head, see CommitOnBranch
+func (r *Repository) GroupCommitOnBranch(groupName, message string) (int, cm.GroupChanges, branch.Branch, bool, error) {
+	commitID := -1
+	var changes cm.GroupChanges
+	var tip branch.Branch
+	var atTip bool
+	err := r.locked(func() (err error) {
+		if tip, atTip, err = branch.GroupTip(r.root, groupName); err != nil {
+			return err
+		}
+		commitID, changes, err = cm.CommitGroup(r.root, groupName, message)
+		return err
+	})
+	return commitID, changes, tip, atTip, err
+}
+
+// Commits every file of the group and returns the new group commit id,
+// along with the files picked up or dropped because of the sources of the group
+func (r *Repository) GroupCommit(groupName, message string) (int, cm.GroupChanges, error) {
+	commitID := -1
+	var changes cm.GroupChanges
+	err := r.locked(func() (err error) {
+		commitID, changes, err = cm.CommitGroup(r.root, groupName, message)
+		return err
+	})
+	return commitID, changes, err
+}
+
+// Returns the commits of the file, the index of a version is its commit id
+func (r *Repository) Log(filePath string) ([]tr.VersionDetails, error) {
+	return cm.GetCommitList(r.root, filePath)
+}
+
+// Returns the moves of the file, see Move
+func (r *Repository) Renames(filePath string) ([]tr.RenameDetails, error) {
+	return cm.GetRenameList(r.root, filePath)
+}
+
+// Stores the keyframes missing in the histories of text files, see repack.Repack.
+// A positive interval first becomes the keyframe interval of the repository, used by later commits as well.
+func (r *Repository) Repack(interval int) (repack.Result, error) {
+	var result repack.Result
+	err := r.locked(func() (err error) {
+		if interval > 0 {
+			if err = repack.SetInterval(r.root, interval); err != nil {
+				return err
+			}
+		}
+		result, err = repack.Repack(r.root)
+		return err
+	})
+	return result, err
+}
+
+// Compares every tracked file against its current version
+func (r *Repository) Status() ([]status.Entry, error) {
+	return status.Status(r.root)
+}
+
+// Compares every file of the group against its version in the current group commit
+// and lists the files matching the sources of the group that are not in it
+func (r *Repository) GroupStatus(groupName string) ([]status.Entry, error) {
+	return status.GroupStatus(r.root, groupName)
+}
+
+// Returns the .qweignore rule deciding whether the path is ignored, nil if no rule matches it.
+// The path is ignored if the rule is not a negation.
+func (r *Repository) CheckIgnore(filePath string) (*utl.IgnoreRule, error) {
+	name, err := utl.Canonical(r.root, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return utl.NewIgnorer(r.root).Match(name, utl.FolderExists(utl.WorkPath(r.root, name)))
+}
+
+// Returns the commits of the group, the index of a version is its commit id
+func (r *Repository) GroupLog(groupName string) ([]tr.GroupVersionDetails, error) {
+	return cm.GetGroupCommitList(r.root, groupName)
+}
+
+// Returns the commit id and details of the checked out version of the file,
+// commit id -2 means the base version is checked out
+func (r *Repository) Current(filePath string) (int, tr.VersionDetails, error) {
+	return cm.CurrentCommit(r.root, filePath)
+}
+
+// Returns the commit id and details of a group commit, commitID -1 refers to the current commit
+func (r *Repository) GroupCurrent(groupName string, commitID int) (int, tr.GroupVersionDetails, error) {
+	return cm.GroupCommitDetails(r.root, groupName, commitID)
+}
+
+// Returns the names of all groups of the repository
+func (r *Repository) Groups() ([]string, error) {
+	return cm.GroupNameList(r.root, "")
+}
+
+// Returns the names of the groups in which the file is tracked
+func (r *Repository) GroupsOf(filePath string) ([]string, error) {
+	return cm.GroupNameList(r.root, filePath)
+}
+
+// Reverts the file to a commit, commitID -1 refers to the head of the checked out branch.
+// Returns the commit id the file has been reverted to.
+func (r *Repository) Revert(filePath string, commitID int) (int, error) {
+	reverted := -1
+	err := r.locked(func() (err error) {
+		reverted, err = rv.Revert(r.root, commitID, filePath)
+		return err
+	})
+	return reverted, err
+}
+
+// Reverts every file of the group to the group commit
+func (r *Repository) GroupRevert(groupName string, commitID int) error {
+	return r.locked(func() error {
+		return rv.RevertGroup(r.root, groupName, commitID)
+	})
+}
+
+// Restores a deleted file to its latest version
+func (r *Repository) Recover(filePath string) error {
+	return r.locked(func() error {
+		return rc.Recover(r.root, filePath)
+	})
+}
+
+// Reverts the file to its base version
+func (r *Repository) Rebase(filePath string) error {
+	return r.locked(func() error {
+		return rb.Rebase(r.root, filePath)
+	})
+}
+
+// Returns the branches of the file, see branch.List
+func (r *Repository) Branches(filePath string) ([]branch.Branch, error) {
+	return branch.List(r.root, filePath)
+}
+
+// Creates a branch of the file at a commit, commitID -1 refers to the checked out version
+func (r *Repository) CreateBranch(filePath, name string, commitID int) error {
+	return r.locked(func() error {
+		return branch.Create(r.root, filePath, name, commitID)
+	})
+}
+
+// Deletes a branch of the file that is not checked out
+func (r *Repository) DeleteBranch(filePath, name string) error {
+	return r.locked(func() error {
+		return branch.Delete(r.root, filePath, name)
+	})
+}
+
+// Returns the checked out branch of the file and whether the checked out version is its head,
+// commits made elsewhere move the head of the branch, see branch.Tip
+func (r *Repository) Tip(filePath string) (branch.Branch, bool, error) {
+	return branch.Tip(r.root, filePath)
+}
+
+// Reverts the file to the head of the branch and checks the branch out.
+// Returns the commit id of the head, -2 for the base version.
+func (r *Repository) Switch(filePath, name string) (int, error) {
+	commitID := -1
+	err := r.locked(func() (err error) {
+		commitID, err = branch.Switch(r.root, filePath, name)
+		return err
+	})
+	return commitID, err
+}
+
+// Returns the branches of the group, see branch.GroupList
+func (r *Repository) GroupBranches(groupName string) ([]branch.Branch, error) {
+	return branch.GroupList(r.root, groupName)
+}
+
+// Creates a branch of the group at a group commit, commitID -1 refers to the current commit
+func (r *Repository) GroupCreateBranch(groupName, name string, commitID int) error {
+	return r.locked(func() error {
+		return branch.GroupCreate(r.root, groupName, name, commitID)
+	})
+}
+
+// Deletes a branch of the group that is not checked out
+func (r *Repository) GroupDeleteBranch(groupName, name string) error {
+	return r.locked(func() error {
+		return branch.GroupDelete(r.root, groupName, name)
+	})
+}
+
+// Returns the checked out branch of the group and whether the current commit is its head
+func (r *Repository) GroupTip(groupName string) (branch.Branch, bool, error) {
+	return branch.GroupTip(r.root, groupName)
+}
+
+// Reverts every file of the group to the head of the branch and checks the branch out.
+// Returns the commit id of the head.
+func (r *Repository) GroupSwitch(groupName, name string) (int, error) {
+	commitID := -1
+	err := r.locked(func() (err error) {
+		commitID, err = branch.GroupSwitch(r.root, groupName, name)
+		return err
+	})
+	return commitID, err
+}
+
+// Returns the tags of the file, see tag.List
+func (r *Repository) Tags(filePath string) ([]tag.Tag, error) {
+	return tag.List(r.root, filePath)
+}
+
+// Tags a commit of the file, ref is a commit number or another tag of the file
+func (r *Repository) CreateTag(filePath, ref, name string) error {
+	return r.locked(func() error {
+		return tag.Create(r.root, filePath, ref, name)
+	})
+}
+
+// Deletes a tag of the file
+func (r *Repository) DeleteTag(filePath, name string) error {
+	return r.locked(func() error {
+		return tag.Delete(r.root, filePath, name)
+	})
+}
+
+// Resolves a commit number or a tag of the file to its commit number, -2 for the base version
+func (r *Repository) ResolveCommit(filePath, ref string) (int, error) {
+	return tag.Resolve(r.root, filePath, ref)
+}
+
+// Returns the tags of the group, see tag.GroupList
+func (r *Repository) GroupTags(groupName string) ([]tag.Tag, error) {
+	return tag.GroupList(r.root, groupName)
+}
+
+// Tags a commit of the group, ref is a commit number or another tag of the group
+func (r *Repository) GroupCreateTag(groupName, ref, name string) error {
+	return r.locked(func() error {
+		return tag.GroupCreate(r.root, groupName, ref, name)
+	})
+}
+
+// Deletes a tag of the group
+func (r *Repository) GroupDeleteTag(groupName, name string) error {
+	return r.locked(func() error {
+		return tag.GroupDelete(r.root, groupName, name)
+	})
+}
+
+// Resolves a commit number or a tag of the group to its commit number
+func (r *Repository) GroupResolveCommit(groupName, ref string) (int, error) {
+	return tag.GroupResolve(r.root, groupName, ref)
+}
+
+// Merges two commits of the file from their common ancestor into the file,
+// uncommitted changes of the file are only overwritten with force, see merge.Merge
+func (r *Repository) Merge(filePath string, commitA, commitB int, force bool) (merge.Result, error) {
+	var result merge.Result
+	err := r.locked(func() (err error) {
+		result, err = merge.Merge(r.root, filePath, commitA, commitB, force)
+		return err
+	})
+	return result, err
+}
+
+// Compares two versions of the file line by line, see diff.Diff for the meaning of the commit ids
+func (r *Repository) Diff(filePath, commitID1, commitID2 string) (diff.Result, error) {
+	return diff.Diff(r.root, filePath, commitID1, commitID2)
+}
+
+// Returns the unified diff hunks between two versions of a text file
+func (r *Repository) UnifiedDiff(filePath, commitID1, commitID2 string, context int) ([]diff.Hunk, error) {
+	return diff.Unified(r.root, filePath, commitID1, commitID2, context)
+}
+
+// Returns true if the repository was created by an older version of qwe and needs to be migrated
+func (r *Repository) NeedsMigration() (bool, error) {
+	return mg.Pending(r.root)
+}
+
+// Upgrades the repository to the latest schema version, returns the descriptions of the applied steps
+func (r *Repository) Migrate() ([]string, error) {
+
+	// Up to date repositories are not locked, so commands reading them never wait for a running commit
+	if pending, err := mg.Pending(r.root); err != nil || !pending {
+		return nil, err
+	}
+	var applied []string
+	err := r.locked(func() (err error) {
+		applied, err = mg.Migrate(r.root)
+		return err
+	})
+	return applied, err
+}
+
+// Checks the integrity of the repository, see fsck.Check. Like the other read-only methods it does not wait
+// for the lock, objects written by a commit that is still in progress may be reported as orphaned.
+func (r *Repository) Fsck() (fsck.Report, error) {
+	return fsck.Check(r.root)
+}
+
+// Removes objects no tracker references and temporary files from the object store, see gc.Collect
+func (r *Repository) GC(dryRun bool) (gc.Result, error) {
+	var result gc.Result
+	err := r.locked(func() (err error) {
+		result, err = gc.Collect(r.root, dryRun)
+		return err
+	})
+	return result, err
+}
+
+// Repairs tracker files left half-written by an interrupted command, returns the descriptions of the repairs made
+func (r *Repository) Repair() ([]string, error) {
+
+	// Only a repository that needs repairs is locked, see Migrate
+	if pending, err := tr.NeedsRepair(r.root); err != nil || !pending {
+		return nil, err
+	}
+	var repairs []string
+	err := r.locked(func() (err error) {
+		repairs, err = tr.Repair(r.root)
+		return err
+	})
+	return repairs, err
+}
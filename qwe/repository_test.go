@@ -0,0 +1,339 @@
+package qwe
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mainak55512/qwe/diff"
+	lk "github.com/mainak55512/qwe/lock"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// captureStdout runs fn and returns everything it wrote to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	writer.Close()
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read stdout: %v", err)
+	}
+	return string(out)
+}
+
+// TestRepository_Lifecycle runs a full track/commit/revert cycle on a repository outside the working directory
+func TestRepository_Lifecycle(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+
+	output := captureStdout(t, func() {
+		repo, err := Init(root)
+		if err != nil {
+			t.Fatalf("Init() failed: %v", err)
+		}
+		if err := os.WriteFile(notes, []byte("first\n"), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		if err := repo.Track("notes.txt"); err != nil {
+			t.Fatalf("Track() failed: %v", err)
+		}
+
+		if err := os.WriteFile(notes, []byte("first\nsecond\n"), 0644); err != nil {
+			t.Fatalf("failed to update test file: %v", err)
+		}
+		commitID, err := repo.Commit("notes.txt", "add second line")
+		if err != nil || commitID != 0 {
+			t.Fatalf("Commit() = %d, %v; want 0, nil", commitID, err)
+		}
+		if _, err := repo.Commit("notes.txt", "nothing changed"); !errors.Is(err, er.NoFileOrDiff) {
+			t.Errorf("expected NoFileOrDiff for unchanged file, got %v", err)
+		}
+
+		if err := os.WriteFile(notes, []byte("first\nsecond\nthird\n"), 0644); err != nil {
+			t.Fatalf("failed to update test file: %v", err)
+		}
+		if commitID, err = repo.Commit("notes.txt", "add third line"); err != nil || commitID != 1 {
+			t.Fatalf("Commit() = %d, %v; want 1, nil", commitID, err)
+		}
+
+		versions, err := repo.Log("notes.txt")
+		if err != nil {
+			t.Fatalf("Log() failed: %v", err)
+		}
+		if len(versions) != 2 || versions[1].CommitMessage != "add third line" {
+			t.Errorf("unexpected versions: %+v", versions)
+		}
+
+		hunks, err := repo.UnifiedDiff("notes.txt", "0", "1", 0)
+		if err != nil || len(hunks) != 1 || hunks[0].NewStart != 3 {
+			t.Errorf("UnifiedDiff() = %+v, %v", hunks, err)
+		}
+
+		if reverted, err := repo.Revert("notes.txt", 0); err != nil || reverted != 0 {
+			t.Fatalf("Revert() = %d, %v; want 0, nil", reverted, err)
+		}
+		content, err := os.ReadFile(notes)
+		if err != nil || string(content) != "first\nsecond\n" {
+			t.Errorf("unexpected content after revert: %q, %v", content, err)
+		}
+		if current, _, err := repo.Current("notes.txt"); err != nil || current != 0 {
+			t.Errorf("Current() = %d, %v; want 0, nil", current, err)
+		}
+	})
+
+	if output != "" {
+		t.Errorf("expected no output on stdout, got %q", output)
+	}
+}
+
+// TestOpen_RepoNotFound tests that opening a folder without a repository fails
+func TestOpen_RepoNotFound(t *testing.T) {
+	if _, err := Open(t.TempDir()); !errors.Is(err, er.RepoNotFound) {
+		t.Errorf("expected RepoNotFound error, got: %v", err)
+	}
+}
+
+// TestDiscover_ParentFolder tests that a repository is found from a nested folder
+func TestDiscover_ParentFolder(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("failed to create nested folder: %v", err)
+	}
+	if _, err := Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+
+	repo, err := Discover(nested)
+	if err != nil {
+		t.Fatalf("Discover() failed: %v", err)
+	}
+	if repo.Root() != root {
+		t.Errorf("expected root %s, got %s", root, repo.Root())
+	}
+
+	// Opening the .qwe folder itself resolves to the same root
+	repo, err = Open(filepath.Join(root, ".qwe"))
+	if err != nil || repo.Root() != root {
+		t.Errorf("Open() on .qwe folder = %v, %v; want root %s", repo, err, root)
+	}
+}
+
+// TestRepository_ReadOnlyObjects tests that reading history never rewrites the objects
+func TestRepository_ReadOnlyObjects(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	repo, err := Init(root)
+	if err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	for i, content := range []string{"a\n", "a\nb\n", "a\nb\nc\n"} {
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+		if i == 0 {
+			err = repo.Track("notes.txt")
+		} else {
+			_, err = repo.Commit("notes.txt", "update")
+		}
+		if err != nil {
+			t.Fatalf("failed to record version %d: %v", i, err)
+		}
+	}
+
+	snapshot := func() map[string]string {
+		objects := make(map[string]string)
+		entries, err := os.ReadDir(filepath.Join(root, ".qwe", "_object"))
+		if err != nil {
+			t.Fatalf("failed to list objects: %v", err)
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				t.Fatalf("failed to stat object: %v", err)
+			}
+			objects[entry.Name()] = info.ModTime().String()
+		}
+		return objects
+	}
+	before := snapshot()
+
+	if _, err := repo.Diff("notes.txt", "0", "1"); err != nil {
+		t.Fatalf("Diff() failed: %v", err)
+	}
+	if _, err := repo.UnifiedDiff("notes.txt", "0", "1", diff.DefaultContext); err != nil {
+		t.Fatalf("UnifiedDiff() failed: %v", err)
+	}
+
+	after := snapshot()
+	if len(before) != len(after) {
+		t.Fatalf("expected %d objects, got %d", len(before), len(after))
+	}
+	for name, modTime := range before {
+		if after[name] != modTime {
+			t.Errorf("object %s was rewritten", name)
+		}
+	}
+}
+
+// TestRepository_GroupAutoTrack tests that a group with sources picks up new files and drops deleted ones
+func TestRepository_GroupAutoTrack(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	captureStdout(t, func() {
+		repo, err := Init(root)
+		if err != nil {
+			t.Fatalf("Init() failed: %v", err)
+		}
+		write("content/index.md", "index\n")
+		write("content/old.md", "old\n")
+		if err := repo.GroupInit("site"); err != nil {
+			t.Fatalf("GroupInit() failed: %v", err)
+		}
+		if _, err := repo.GroupTrack("site", []string{"content/**/*.md"}, false, true); err != nil {
+			t.Fatalf("GroupTrack() failed: %v", err)
+		}
+
+		write("content/posts/new.md", "new\n")
+		write("content/posts/image.txt", "not matched\n")
+		if err := os.Remove(filepath.Join(root, "content", "old.md")); err != nil {
+			t.Fatal(err)
+		}
+		commitID, changes, err := repo.GroupCommit("site", "new post")
+		if err != nil || commitID != 1 {
+			t.Fatalf("GroupCommit() = %d, %v; want 1, nil", commitID, err)
+		}
+		if !slices.Equal(changes.Added, []string{"content/posts/new.md"}) || !slices.Equal(changes.Removed, []string{"content/old.md"}) {
+			t.Errorf("GroupCommit() changes = %+v", changes)
+		}
+
+		_, details, err := repo.GroupCurrent("site", -1)
+		if err != nil {
+			t.Fatalf("GroupCurrent() failed: %v", err)
+		}
+		var files []string
+		for _, file := range details.Files {
+			files = append(files, file.FileName)
+		}
+		slices.Sort(files)
+		if !slices.Equal(files, []string{"content/index.md", "content/posts/new.md"}) {
+			t.Errorf("files of the group commit = %v", files)
+		}
+
+		// Nothing changes when the files on disk still match the group
+		if _, changes, err := repo.GroupCommit("site", "again"); err != nil || len(changes.Added)+len(changes.Removed) != 0 {
+			t.Errorf("GroupCommit() = %+v, %v; want no changes", changes, err)
+		}
+	})
+}
+
+// TestRepository_ReadWhileLocked tests that reading an up to date repository does not wait for the lock held by another command
+func TestRepository_ReadWhileLocked(t *testing.T) {
+	root := t.TempDir()
+	repo, err := Init(root)
+	if err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Track("notes.txt"); err != nil {
+		t.Fatalf("Track() failed: %v", err)
+	}
+
+	l, err := lk.Acquire(root, 0)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	defer l.Release()
+	repo.SetLockTimeout(100 * time.Millisecond)
+
+	if repairs, err := repo.Repair(); err != nil || len(repairs) != 0 {
+		t.Errorf("Repair() = %v, %v; want nothing to repair", repairs, err)
+	}
+	if applied, err := repo.Migrate(); err != nil || len(applied) != 0 {
+		t.Errorf("Migrate() = %v, %v; want nothing to migrate", applied, err)
+	}
+	if _, err := repo.Status(); err != nil {
+		t.Errorf("Status() failed: %v", err)
+	}
+	if _, err := repo.Fsck(); err != nil {
+		t.Errorf("Fsck() failed: %v", err)
+	}
+
+	// An unfinished tracker write needs the lock to be repaired
+	if err := os.WriteFile(utl.TempPath(utl.QwePath(root, "_tracker.qwe")), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Repair(); !errors.Is(err, er.RepoLocked) {
+		t.Errorf("expected RepoLocked, got %v", err)
+	}
+}
+
+// TestRepository_CommitOnBranch tests that committing reports whether the file was at the head of its branch
+func TestRepository_CommitOnBranch(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	repo, err := Init(root)
+	if err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	commit := func(content string) (int, bool) {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		commitID, tip, atTip, err := repo.CommitOnBranch("notes.txt", content)
+		if err != nil {
+			t.Fatalf("CommitOnBranch() failed: %v", err)
+		}
+		if !atTip && tip.Commit != 1 {
+			t.Errorf("CommitOnBranch() reported the head at commit %d; want 1", tip.Commit)
+		}
+		return commitID, atTip
+	}
+	if err := os.WriteFile(notes, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Track("notes.txt"); err != nil {
+		t.Fatalf("Track() failed: %v", err)
+	}
+	commit("b\n")
+	if _, atTip := commit("c\n"); !atTip {
+		t.Errorf("commit at the head of the branch reported off the head")
+	}
+
+	if _, err := repo.Revert("notes.txt", 0); err != nil {
+		t.Fatalf("Revert() failed: %v", err)
+	}
+	if commitID, atTip := commit("b2\n"); atTip || commitID != 2 {
+		t.Errorf("CommitOnBranch() = %d, %v; want 2 off the head", commitID, atTip)
+	}
+	if _, atTip := commit("b3\n"); !atTip {
+		t.Errorf("commit on top of the new head reported off the head")
+	}
+}
@@ -44,8 +44,8 @@ var (
 	DecompBufInitErr   = new(23, "Can not initialize decompression buffer!")
 	CLIInitErr         = new(24, "init command doesn't take any argument!")
 	CLIGrpInitErr      = new(25, "group-init command only takes 'group name' as argument!")
-	CLITrackErr        = new(26, "track command only accepts 'file path' as argument!")
-	CLIGrpTrackErr     = new(27, "group-track command accepts 'group name' and 'file path' as arguments!")
+	CLITrackErr        = new(26, "track command accepts one or more 'file path', 'folder path' or glob patterns and optionally '--recursive' as arguments!")
+	CLIGrpTrackErr     = new(27, "group-track command accepts 'group name', optionally '--recursive' and '--auto', and one or more 'file path', 'folder path' or glob patterns as arguments!")
 	CLICommitErr       = new(28, "commit command accepts 'file path' and 'commit message' as arguments!")
 	CLIGrpCommitErr    = new(29, "group-commit command accepts 'group name' and 'commit message' as arguments!")
 	CLIListErr         = new(30, "list command only accepts 'file path' as argument!")
@@ -60,4 +60,40 @@ var (
 	NoFileOrDiff       = new(39, "File does not exist or no changes found with the previous commit!")
 	GrpNameListErr     = new(40, "groups command takes no argument or filepath as the only argument!")
 	BinFileErr         = new(41, "Filetype is not supported yet!")
+	InvalidDelta       = new(42, "Commit object is corrupted or has an unknown format!")
+	CLIDiffFlagErr     = new(43, "diff command only accepts '--format=unified' and '--context=<number of lines>' as options!")
+	PathOutsideRepo    = new(44, "Path is outside of the repository!")
+	CLIRepoFlagErr     = new(45, "--repo option requires the path of a repository!")
+	SchemaUnsupported  = new(46, "Repository was created by a newer version of qwe!")
+	CLIMigrateErr      = new(47, "migrate command doesn't take any argument!")
+	TrackerCorrupted   = new(48, "Tracker file is corrupted and can not be repaired!")
+	RepoLocked         = new(49, "Repository is locked by another qwe process!")
+	RepoCorrupted      = new(50, "Repository has integrity problems!")
+	CLIFsckErr         = new(51, "fsck command only accepts '--json' as option!")
+	CLIGcErr           = new(52, "gc command only accepts '--dry-run' as option!")
+	FileInGroup        = new(53, "File is tracked in a group, use --force to untrack it anyway!")
+	CLIUntrackErr      = new(54, "untrack command accepts 'file path' and optionally '--force' as arguments!")
+	CLIGrpDeleteErr    = new(55, "group-delete command only accepts 'group name' as argument!")
+	CLIGrpUntrackErr   = new(56, "group-untrack command accepts 'group name' and 'file path' as arguments!")
+	CLIMvErr           = new(57, "mv command accepts 'old file path' and 'new file path' as arguments!")
+	NoMatch            = new(58, "No file matches the pattern!")
+	InvalidPattern     = new(59, "Invalid glob pattern!")
+	CLICheckIgnoreErr  = new(60, "check-ignore command only accepts 'path' as argument!")
+	CLIStatusErr       = new(61, "status command accepts optionally '--json', group-status command accepts 'group name' and optionally '--json' as arguments!")
+	InvalidInterval    = new(62, "Keyframe interval must be a positive number!")
+	CLIRepackErr       = new(63, "repack command only accepts optionally '--interval <number>' as arguments!")
+	InvalidBranch      = new(64, "Branch does not exist!")
+	BranchExists       = new(65, "Branch already exists!")
+	InvalidBranchName  = new(66, "Branch name must not be empty, contain white space or start with '-'!")
+	BranchCheckedOut   = new(67, "Checked out branch can not be deleted, switch to another branch first!")
+	CLIBranchErr       = new(68, "branch command accepts 'file path' and optionally 'branch name' and 'commit number', or '--delete', 'file path' and 'branch name' as arguments, group-branch command the same with 'group name'!")
+	CLISwitchErr       = new(69, "switch command accepts 'file path' and 'branch name', group-switch command 'group name' and 'branch name' as arguments!")
+	MergeConflict      = new(70, "Merge has conflicts, resolve the conflict markers in the file before committing it!")
+	CLIMergeErr        = new(71, "merge command accepts 'file path', two commit numbers and optionally '--force' as arguments!")
+	BrokenHistory      = new(72, "Version history of the file is broken, run 'qwe fsck' for details!")
+	InvalidTag         = new(73, "No commit or tag of that name!")
+	TagExists          = new(74, "Tag already exists!")
+	InvalidTagName     = new(75, "Tag name must not be a number or 'uncommitted', contain white space or start with '-'!")
+	CLITagErr          = new(76, "tag command accepts 'file path' and optionally 'commit number or tag' and 'tag name', or '--delete', 'file path' and 'tag name' as arguments, group-tag command the same with 'group name'!")
+	UncommittedChanges = new(77, "File has uncommitted changes, commit them or use --force to overwrite them!")
 )
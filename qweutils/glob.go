@@ -0,0 +1,122 @@
+package qweutils
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Reports whether the path contains wildcards
+func HasGlobMeta(filePath string) bool {
+	return strings.ContainsAny(filePath, "*?[")
+}
+
+// Reports whether a slash separated path matches the pattern. Each segment of the pattern
+// may use the wildcards of path.Match, a '**' segment matches any number of folders.
+func MatchPath(pattern, name string) (bool, error) {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if ok, err := matchSegments(pattern[1:], name[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+// Returns the canonical paths of the files in a folder of the repository at root, sorted.
+// folder is a canonical path, "" stands for the root. Subfolders are only walked if recursive is set,
+// the .qwe folder and paths ignored by .qweignore files are always skipped.
+func ListFiles(root, folder string, recursive bool) ([]string, error) {
+	start := WorkPath(root, filepath.FromSlash(folder))
+	ignorer := NewIgnorer(root)
+	var files []string
+	err := filepath.WalkDir(start, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == start {
+			if !entry.IsDir() {
+				return fs.ErrNotExist
+			}
+			if folder == "" {
+				return nil
+			}
+		} else if entry.IsDir() && (!recursive || entry.Name() == QweDir) {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		ignored, err := ignorer.Ignored(rel, entry.IsDir())
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if ignored {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !ignored {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Returns the canonical paths of the files of the repository at root matching a canonical pattern, sorted.
+// Only the folder below the part of the pattern without wildcards is walked.
+func Glob(root, pattern string) ([]string, error) {
+	segments := strings.Split(pattern, "/")
+	fixed := 0
+	for fixed < len(segments)-1 && !HasGlobMeta(segments[fixed]) {
+		fixed++
+	}
+
+	// Subfolders only matter if the pattern goes deeper than the next level
+	recursive := fixed < len(segments)-1
+	candidates, err := ListFiles(root, path.Join(segments[:fixed]...), recursive)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		ok, err := MatchPath(pattern, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches, nil
+}
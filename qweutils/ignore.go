@@ -0,0 +1,167 @@
+package qweutils
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Name of the files listing the paths that folder walks skip, in the syntax of .gitignore
+const IgnoreFile = ".qweignore"
+
+// Pattern read from an ignore file
+type IgnoreRule struct {
+	Source  string // canonical path of the ignore file
+	Line    int
+	Pattern string // as written in the ignore file
+	Negate  bool   // the pattern starts with '!' and re-includes what it matches
+
+	base     string // canonical folder of the ignore file, "" for the root
+	glob     string
+	dirOnly  bool // the pattern ends with '/'
+	anchored bool // the pattern contains a '/' and is matched relative to the folder of the ignore file
+}
+
+// Matches paths of the repository at root against the ignore files of the root and its folders.
+// The ignore files are read once and cached.
+type Ignorer struct {
+	root  string
+	rules map[string][]IgnoreRule // by canonical folder
+}
+
+// Returns an Ignorer for the repository at root
+func NewIgnorer(root string) *Ignorer {
+	return &Ignorer{root: root, rules: make(map[string][]IgnoreRule)}
+}
+
+// Returns the rule deciding whether a canonical path is ignored, nil if no rule matches.
+// Like git, files in an ignored folder are ignored whatever rules match them,
+// the rule ignoring the folder is returned then. isDir tells if the path is a folder.
+func (ig *Ignorer) Match(name string, isDir bool) (*IgnoreRule, error) {
+	segments := strings.Split(name, "/")
+	for i := 1; i < len(segments); i++ {
+		rule, err := ig.matchRules(path.Join(segments[:i]...), true)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil && !rule.Negate {
+			return rule, nil
+		}
+	}
+	return ig.matchRules(name, isDir)
+}
+
+// Reports whether a canonical path is ignored, see Match
+func (ig *Ignorer) Ignored(name string, isDir bool) (bool, error) {
+	rule, err := ig.Match(name, isDir)
+	return rule != nil && !rule.Negate, err
+}
+
+// Returns the last rule matching the path itself, rules of deeper ignore files take precedence
+func (ig *Ignorer) matchRules(name string, isDir bool) (*IgnoreRule, error) {
+	var match *IgnoreRule
+	folder := ""
+	rest := name
+	for {
+		rules, err := ig.load(folder)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rules {
+			if rules[i].matches(name, isDir) {
+				match = &rules[i]
+			}
+		}
+		segment, remaining, found := strings.Cut(rest, "/")
+		if !found {
+			return match, nil
+		}
+		folder = path.Join(folder, segment)
+		rest = remaining
+	}
+}
+
+// Returns the rules of the ignore file in a canonical folder
+func (ig *Ignorer) load(folder string) ([]IgnoreRule, error) {
+	if rules, ok := ig.rules[folder]; ok {
+		return rules, nil
+	}
+	source := path.Join(folder, IgnoreFile)
+	file, err := os.Open(WorkPath(ig.root, filepath.FromSlash(source)))
+	if errors.Is(err, fs.ErrNotExist) {
+		ig.rules[folder] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []IgnoreRule
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if rule, ok := parseRule(scanner.Text()); ok {
+			rule.Source, rule.Line, rule.base = source, line, folder
+			rules = append(rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	ig.rules[folder] = rules
+	return rules, nil
+}
+
+// Parses a line of an ignore file, blank lines and comments are no rules
+func parseRule(line string) (IgnoreRule, bool) {
+	rule := IgnoreRule{Pattern: line}
+	pattern := strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return rule, false
+	}
+	if strings.HasPrefix(pattern, "!") {
+		rule.Negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\#`) || strings.HasPrefix(pattern, `\!`) {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	rule.anchored = strings.Contains(pattern, "/")
+	rule.glob = strings.TrimPrefix(pattern, "/")
+	return rule, rule.glob != ""
+}
+
+// Reports whether the rule matches a canonical path below the folder of its ignore file
+func (rule IgnoreRule) matches(name string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	rel := name
+	if rule.base != "" {
+		if !strings.HasPrefix(name, rule.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(name, rule.base+"/")
+	}
+	var ok bool
+	if rule.anchored {
+		ok, _ = MatchPath(rule.glob, rel)
+
+		// Like git, a trailing "/**" matches everything inside a folder but not the folder itself
+		if prefix, found := strings.CutSuffix(rule.glob, "/**"); ok && found {
+			if self, _ := MatchPath(prefix, rel); self {
+				ok = false
+			}
+		}
+	} else {
+		ok, _ = path.Match(rule.glob, path.Base(rel))
+	}
+	return ok
+}
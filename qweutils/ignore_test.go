@@ -0,0 +1,126 @@
+package qweutils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates the files below root with the given content
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for file, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestIgnorer tests the gitignore semantics of .qweignore files
+func TestIgnorer(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".qweignore":      "# editor files\n*.swp\n.DS_Store\nbuild/\n/out\n!keep.swp\n",
+		"src/.qweignore":  "gen/*.go\n!build\n",
+		"docs/.qweignore": "*.md\n!README.md\n",
+	})
+
+	tests := []struct {
+		name    string
+		isDir   bool
+		ignored bool
+		source  string
+		line    int
+	}{
+		{"notes.swp", false, true, ".qweignore", 2},
+		{"src/a/.DS_Store", false, true, ".qweignore", 3},
+		{"keep.swp", false, false, ".qweignore", 6},
+		{"build", true, true, ".qweignore", 4},
+		{"build", false, false, "", 0},
+		{"build/app", false, true, ".qweignore", 4},
+		{"out", false, true, ".qweignore", 5},
+		{"src/out", false, false, "", 0},
+		{"src/gen/x.go", false, true, "src/.qweignore", 1},
+		{"src/gen/sub/x.go", false, false, "", 0},
+		{"src/build", true, false, "src/.qweignore", 2},
+		{"docs/guide.md", false, true, "docs/.qweignore", 1},
+		{"docs/README.md", false, false, "docs/.qweignore", 2},
+		{"README.md", false, false, "", 0},
+	}
+	ig := NewIgnorer(root)
+	for _, tt := range tests {
+		rule, err := ig.Match(tt.name, tt.isDir)
+		if err != nil {
+			t.Fatalf("Match(%q) failed: %v", tt.name, err)
+		}
+		ignored := rule != nil && !rule.Negate
+		if ignored != tt.ignored {
+			t.Errorf("Match(%q, %v) ignored = %v, want %v", tt.name, tt.isDir, ignored, tt.ignored)
+		}
+		if tt.source == "" && rule != nil {
+			t.Errorf("Match(%q) = %s:%d, want no rule", tt.name, rule.Source, rule.Line)
+		}
+		if tt.source != "" && (rule == nil || rule.Source != tt.source || rule.Line != tt.line) {
+			t.Errorf("Match(%q) = %+v, want %s:%d", tt.name, rule, tt.source, tt.line)
+		}
+	}
+}
+
+// TestIgnorer_FolderContents tests that "dir/**" ignores the contents of a folder but not the folder,
+// so files inside it can be re-included
+func TestIgnorer_FolderContents(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".qweignore": "logs/**\n!logs/keep\n",
+	})
+
+	tests := []struct {
+		name    string
+		isDir   bool
+		ignored bool
+	}{
+		{"logs", true, false},
+		{"logs/app.log", false, true},
+		{"logs/keep", false, false},
+		{"logs/old", true, true},
+		{"logs/old/keep", false, true},
+	}
+	ig := NewIgnorer(root)
+	for _, tt := range tests {
+		ignored, err := ig.Ignored(tt.name, tt.isDir)
+		if err != nil {
+			t.Fatalf("Ignored(%q) failed: %v", tt.name, err)
+		}
+		if ignored != tt.ignored {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.name, tt.isDir, ignored, tt.ignored)
+		}
+	}
+}
+
+// TestListFiles_Ignored tests that folder walks skip ignored paths
+func TestListFiles_Ignored(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".qweignore":        "*.swp\nbuild/\n",
+		"a.txt":             "",
+		"a.txt.swp":         "",
+		"build/app":         "",
+		"src/main.go":       "",
+		"src/.main.go.swp":  "",
+		QweDir + "/tracker": "",
+	})
+	files, err := ListFiles(root, "", true)
+	want := []string{".qweignore", "a.txt", "src/main.go"}
+	if err != nil || !reflect.DeepEqual(files, want) {
+		t.Errorf("ListFiles() = %v, %v; want %v", files, err, want)
+	}
+	files, err = Glob(root, "**/*")
+	if err != nil || !reflect.DeepEqual(files, want) {
+		t.Errorf("Glob() = %v, %v; want %v", files, err, want)
+	}
+}
@@ -6,13 +6,132 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	// er "github.com/mainak55512/qwe/qwerror"
-	// "io"
+	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	// "unicode"
+
+	er "github.com/mainak55512/qwe/qwerror"
 )
 
+// Name of the folder holding the repository data inside the repository root
+const QweDir = ".qwe"
+
+// Returns the path of a file or folder inside the .qwe folder of the repository at root
+func QwePath(root string, elem ...string) string {
+	return filepath.Join(append([]string{root, QweDir}, elem...)...)
+}
+
+// Returns the path of an object of the repository at root.
+// Content addressed objects are spread over subfolders named after the first two characters of their hash,
+// objects written before content addressing are stored directly in the _object folder.
+func ObjectPath(root, objID string) string {
+	if IsContentID(objID) {
+		hash := ObjectHash(objID)
+		return QwePath(root, "_object", hash[:2], objID)
+	}
+	return QwePath(root, "_object", objID)
+}
+
+// Returns the hash part of an object ID, without its _base_ or _bin_ prefix
+func ObjectHash(objID string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(objID, "_base_"), "_bin_")
+}
+
+// Reports whether the object ID is derived from the content of the object
+func IsContentID(objID string) bool {
+	return len(ObjectHash(objID)) == sha256.Size*2
+}
+
+// Returns the hash of the uncompressed content of an object, used as its ID
+func ContentID(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// Returns the hash of the content of a file like ContentID, the file is read in chunks
+func FileContentID(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Walks up from start until a folder containing a .qwe folder is found and returns its absolute path
+func FindRoot(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if FolderExists(filepath.Join(dir, QweDir)) {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", er.RepoNotFound
+		}
+		dir = parent
+	}
+}
+
+// Returns the canonical name of a file used to identify it in the trackers:
+// a clean, slash separated path relative to the repository root.
+// Relative paths are interpreted relative to the root. Symlinked folders in the path
+// are resolved, a symlink to a file is identified by its own name and not by its target.
+func Canonical(root, filePath string) (string, error) {
+	if filePath == "" {
+		return "", er.InvalidFile
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath := filepath.Clean(filePath)
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(absRoot, absPath)
+	}
+
+	// Only the folders are resolved, the file name is kept as it is
+	rel, err := filepath.Rel(resolveDir(absRoot), filepath.Join(resolveDir(filepath.Dir(absPath)), filepath.Base(absPath)))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", er.PathOutsideRepo, filePath)
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == QweDir || strings.HasPrefix(rel, QweDir+"/") {
+		return "", fmt.Errorf("%w: %s", er.InvalidFile, filePath)
+	}
+	return rel, nil
+}
+
+// Resolves symlinks of a folder, parts of the path that do not exist are kept as they are
+func resolveDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(dir)
+	if parent == dir {
+		return dir
+	}
+	return filepath.Join(resolveDir(parent), filepath.Base(dir))
+}
+
+// Returns the on-disk path of a file argument, relative paths are resolved against the repository root
+func WorkPath(root, filePath string) string {
+	if filepath.IsAbs(filePath) {
+		return filePath
+	}
+	return filepath.Join(root, filePath)
+}
+
 // Encodes strings to base64
 func ConvStrEnc(str string) string {
 	return base64.StdEncoding.EncodeToString([]byte(str))
@@ -61,3 +180,39 @@ func FileExists(filePath string) bool {
 	}
 	return false
 }
+
+// Returns the path of the temporary file used while atomically replacing filePath
+func TempPath(filePath string) string {
+	return filePath + ".tmp"
+}
+
+// Replaces the content of a file so that a crash leaves either the old or the new content:
+// data is written to a temporary file which is synced and then renamed over the file
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	tmpPath := TempPath(filePath)
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Persist the rename itself, not every platform supports syncing a folder
+	if dir, err := os.Open(filepath.Dir(filePath)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
@@ -2,10 +2,10 @@ package rebase
 
 import (
 	"encoding/json"
-	"fmt"
 	"strings"
 
 	bh "github.com/mainak55512/qwe/binaryhandler"
+	"github.com/mainak55512/qwe/index"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 	res "github.com/mainak55512/qwe/reconstruct"
@@ -13,10 +13,16 @@ import (
 )
 
 // Reverts a file back to its base version
-func Rebase(filePath string) error {
+func Rebase(root, filePath string) error {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return err
+	}
 
 	// Get tracker details
-	tracker, _, err := tr.GetTracker(0)
+	tracker, _, err := tr.GetTracker(root, 0)
 	if err != nil {
 		return err
 	}
@@ -30,13 +36,13 @@ func Rebase(filePath string) error {
 	}
 
 	if strings.HasPrefix(val.Base, "_bin_") {
-		if err = bh.RevertBinFile(filePath, val.Base); err != nil {
+		if err = bh.RevertBinFile(root, utl.WorkPath(root, filePath), val.Base, ""); err != nil {
 			return err
 		}
 	}
 
 	// Reconstruct the file till its base version
-	if err = res.Reconstruct(val, filePath, -2); err != nil {
+	if err = res.Reconstruct(root, val, utl.WorkPath(root, filePath), -2); err != nil {
 		return err
 	}
 
@@ -49,9 +55,11 @@ func Rebase(filePath string) error {
 	}
 
 	// Update the tracker
-	if err = tr.SaveTracker(0, marshalContent); err != nil {
+	if err = tr.SaveTracker(root, 0, marshalContent); err != nil {
 		return err
 	}
-	fmt.Println("Successfully reverted", filePath, "back to base version")
+
+	// The index only speeds up later commits, failing to update it does not fail the rebase
+	index.Record(root, fileId, utl.WorkPath(root, filePath), val.Current, val.ContentHash(val.Current))
 	return nil
 }
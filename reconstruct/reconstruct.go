@@ -1,151 +1,68 @@
 package reconstruct
 
 import (
-	"bufio"
-	// bh "github.com/mainak55512/qwe/binaryhandler"
+	"os"
+	"strings"
+
 	cp "github.com/mainak55512/qwe/compressor"
+	dl "github.com/mainak55512/qwe/delta"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 	tr "github.com/mainak55512/qwe/tracker"
-	"io"
-	"os"
-	"strconv"
-	"strings"
 )
 
-// Applies previous commits till the commitID supplied on to the base version
-func Reconstruct(val tr.Tracker, target string, commitID int) error {
-	// if strings.HasPrefix(val.Base, "_bin_") {
-	// 	if err := bh.RevertBinFile(target, val.Current); err != nil {
-	// 		return err
-	// 	}
-	// 	return nil
-	// }
-	buf := make([]byte, 1024)
-
-	// Decompress the base varient
-	if err := cp.DecompressFile(".qwe/_object/" + val.Base); err != nil {
-		return err
-	}
+// Reads the uncompressed content of an object, the object is decompressed in memory only
+func ReadObject(root, objID string) ([]byte, error) {
+	return cp.ReadFile(utl.ObjectPath(root, objID))
+}
 
-	base_content, err := os.Open(".qwe/_object/" + val.Base)
-	if err != nil {
-		return err
+// Returns the lines of the file at the commitID by applying the commits it descends from on to the base version,
+// -1 refers to the latest commit and -2 returns the base version only.
+// Replaying starts at the nearest keyframe among the commit and its ancestors instead of the base version.
+func Lines(root string, val tr.Tracker, commitID int) ([]string, error) {
+
+	// If commitID is -2 or nothing was committed yet only the base varient is needed
+	switch {
+	case commitID < -1 || len(val.Versions) == 0:
+		commitID = -2
+	case commitID == -1:
+		commitID = len(val.Versions) - 1
 	}
 
-	target_content, err := os.Create(target)
+	startObj, chain, err := val.DeltaChain(commitID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Copy the content from base varient to the file
-	_, err = io.CopyBuffer(target_content, base_content, buf)
+	content, err := ReadObject(root, startObj)
 	if err != nil {
-		return err
-	}
-	base_content.Close()
-
-	// Compress the base varient
-	if err = cp.CompressFile(".qwe/_object/" + val.Base); err != nil {
-		return err
-	}
-	target_content.Close()
-
-	// if commitID is -2, that means only base varient is needed
-	if commitID == -2 {
-		return nil
+		return nil, err
 	}
+	lines := dl.SplitLines(content)
 
-	// Loop through the file versions and apply the changes to the base varient one by one
-	for i, elem := range val.Versions {
-
-		// Will stop if the specified commitID is reached; -1 means it will cover all versions
-		if commitID != -1 && i > commitID {
-			break
-		}
-
-		if err = cp.DecompressFile(".qwe/_object/" + elem.UID); err != nil {
-			return err
-		}
-
-		diff_file, err := os.Open(".qwe/_object/" + elem.UID)
+	// Loop through the versions from the starting point to the commit and apply the changes one by one
+	for _, i := range chain {
+		diff_content, err := ReadObject(root, val.Versions[i].Object())
 		if err != nil {
-			return err
+			return nil, err
 		}
-		// defer diff_file.Close()
-		diff_scanner := bufio.NewScanner(diff_file)
 
-		base_file, err := os.Open(target)
-		if err != nil {
-			return err
-		}
-		// defer base_file.Close()
-		base_scanner := bufio.NewScanner(base_file)
-
-		var output string
-
-		// This will retrieve the line number from the commit file,
-		// reconstructed file should only have these many lines in it.
-		diff_scanner.Scan()
-
-		total_lines, err := strconv.Atoi(diff_scanner.Text())
-		if err != nil {
-			return err
-		}
-
-		// Retrieving a line from commit file
-		diff_scanner.Scan()
-		idx := 1
-		for i := 0; i < total_lines; i++ {
-
-			// Retrieve a line from base file
-			base_scanner.Scan()
-
-			// Split the line from the commit file
-			// to get the line number where the change occured and the content as string
-			comp := strings.Split(diff_scanner.Text(), " @@@ ")
-			line_number, _ := strconv.Atoi(comp[0])
-
-			// if current line number of the base file is same as the retrieved line number
-			// from the commit file, then decompress the string from the commit file and
-			// add it to the output string and scan the next line from the commit file,
-			// else add the line from the base file to the output string
-			if idx == line_number {
-				dec_str, err := utl.ConvStrDec(comp[1])
-				if err != nil {
-					return err
-				}
-				output += dec_str + "\n"
-				diff_scanner.Scan()
-			} else {
-				output += base_scanner.Text() + "\n"
-			}
-			idx++
-		}
-
-		diff_file.Close()
-
-		// Compress the commit file
-		if err = cp.CompressFile(".qwe/_object/" + elem.UID); err != nil {
-			return err
+		if lines, err = dl.Apply(lines, diff_content); err != nil {
+			return nil, err
 		}
-		base_file.Close()
+	}
+	return lines, nil
+}
 
-		output_content, err := os.Create(target)
-		if err != nil {
-			return err
-		}
+// Reconstructs the file at the commitID, see Lines, and writes it to target
+func Reconstruct(root string, val tr.Tracker, target string, commitID int) error {
+	lines, err := Lines(root, val, commitID)
+	if err != nil {
+		return err
+	}
 
-		// Write all the changes to the file
-		output_writer := bufio.NewWriter(output_content)
-		_, err = output_writer.WriteString(output)
-		if err != nil {
-			return er.BaseWriteErr
-		}
-		if err = output_writer.Flush(); err != nil {
-			return er.OutputWriteErr
-		}
-		output_content.Close()
+	// Write all the changes to the file
+	if err = os.WriteFile(target, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return er.OutputWriteErr
 	}
 	return nil
 }
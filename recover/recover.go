@@ -1,26 +1,32 @@
 package recover
 
 import (
-	"fmt"
 	"strings"
 
 	bh "github.com/mainak55512/qwe/binaryhandler"
+	"github.com/mainak55512/qwe/index"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 	res "github.com/mainak55512/qwe/reconstruct"
 	tr "github.com/mainak55512/qwe/tracker"
 )
 
-// Restores a deleted file if it was earlier tracked by qwe
-func Recover(filePath string) error {
+// Restores a deleted file if it was earlier tracked by qwe, at its checked out version
+func Recover(root, filePath string) error {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return err
+	}
 
 	// Check if the file is present before recovering
-	if exists := utl.FileExists(filePath); exists {
+	if exists := utl.FileExists(utl.WorkPath(root, filePath)); exists {
 		return er.FileExists
 	}
 
 	// Get tracker details
-	tracker, _, err := tr.GetTracker(0)
+	tracker, _, err := tr.GetTracker(root, 0)
 	if err != nil {
 		return err
 	}
@@ -30,24 +36,35 @@ func Recover(filePath string) error {
 		return er.FileNotTracked
 	}
 
-	target := filePath
+	target := utl.WorkPath(root, filePath)
 
 	// isBin, err := bh.CheckBinFile(filePath)
 	// if err != nil {
 	// 	return err
 	// }
 
+	// The file is restored at the checked out version, which need not be the latest one after a revert or switch
+	commitNumber := val.CommitNumber(val.Current)
+	if commitNumber == -3 {
+		return er.BrokenHistory
+	}
+
 	if strings.HasPrefix(val.Base, "_bin_") {
-		if err := bh.RevertBinFile(filePath, val.Current); err != nil {
+		objID, deltaOf := val.Base, ""
+		if commitNumber >= 0 {
+			objID, deltaOf = val.Versions[commitNumber].Object(), val.Versions[commitNumber].DeltaOf
+		}
+		if err := bh.RevertBinFile(root, target, objID, deltaOf); err != nil {
 			return err
 		}
 	} else {
-		// Reconstruct the file all the way to the latest version
-		if err = res.Reconstruct(val, target, -1); err != nil {
+		// Reconstruct the file till the checked out version, -2 refers to the base version
+		if err = res.Reconstruct(root, val, target, commitNumber); err != nil {
 			return err
 		}
 	}
 
-	fmt.Println("Successfully recovered", filePath)
+	// The index only speeds up later commits, failing to update it does not fail the recovery
+	index.Record(root, fileId, target, val.Current, val.ContentHash(val.Current))
 	return nil
 }
@@ -0,0 +1,83 @@
+package recover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mainak55512/qwe/branch"
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	rb "github.com/mainak55512/qwe/rebase"
+	rv "github.com/mainak55512/qwe/revert"
+	"github.com/mainak55512/qwe/status"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestRecover tests that a deleted file comes back at its checked out version after reverting and switching
+func TestRecover(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(content string) {
+		t.Helper()
+		write(content)
+		if _, _, err := cm.CommitUnit(root, "notes.txt", content); err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+	}
+	recoverAs := func(content string) {
+		t.Helper()
+		if err := os.Remove(notes); err != nil {
+			t.Fatal(err)
+		}
+		if err := Recover(root, "notes.txt"); err != nil {
+			t.Fatalf("Recover() failed: %v", err)
+		}
+		if got, _ := os.ReadFile(notes); string(got) != content {
+			t.Errorf("recovered content = %q; want %q", got, content)
+		}
+		entries, err := status.Status(root)
+		if err != nil || len(entries) != 1 || entries[0].State != status.Unchanged {
+			t.Errorf("Status() = %+v, %v; want the file unchanged", entries, err)
+		}
+	}
+
+	write("a\n")
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	commit("b\n")
+	commit("c\n")
+
+	if _, err := rv.Revert(root, 0, "notes.txt"); err != nil {
+		t.Fatalf("Revert() failed: %v", err)
+	}
+	recoverAs("b\n")
+
+	// Switching checks out the head of the other branch
+	if err := branch.Create(root, "notes.txt", "exp", -1); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if _, err := branch.Switch(root, "notes.txt", tr.DefaultBranch); err != nil {
+		t.Fatalf("Switch() failed: %v", err)
+	}
+	recoverAs("c\n")
+	if _, err := branch.Switch(root, "notes.txt", "exp"); err != nil {
+		t.Fatalf("Switch() failed: %v", err)
+	}
+	recoverAs("b\n")
+
+	if err := rb.Rebase(root, "notes.txt"); err != nil {
+		t.Fatalf("Rebase() failed: %v", err)
+	}
+	recoverAs("a\n")
+}
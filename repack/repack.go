@@ -0,0 +1,97 @@
+package repack
+
+import (
+	"encoding/json"
+	"strings"
+
+	ob "github.com/mainak55512/qwe/object"
+	er "github.com/mainak55512/qwe/qwerror"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Outcome of a repack
+type Result struct {
+	Files     int `json:"files"` // text files that got keyframes
+	Keyframes int `json:"keyframes"`
+}
+
+// Stores a keyframe for every version of a text file whose reconstruction would replay as many deltas
+// as the keyframe interval of the repository, histories committed before keyframes existed or under
+// another interval then reconstruct as quickly as new ones. Existing keyframes are kept.
+func Repack(root string) (Result, error) {
+	var result Result
+
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return result, err
+	}
+	interval := meta.Keyframes()
+
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return result, err
+	}
+
+	for fileId, val := range tracker {
+		if strings.HasPrefix(val.Base, "_bin_") {
+			continue
+		}
+		added := 0
+
+		// Number of deltas replayed to reconstruct each version, parents are committed before their children
+		depth := make([]int, len(val.Versions))
+		for i := range val.Versions {
+			if parent := val.Parent(i); parent >= 0 && parent < i {
+				depth[i] = depth[parent]
+			}
+			depth[i]++
+			if val.Versions[i].Snapshot != "" {
+				depth[i] = 0
+			}
+			if !tr.IsKeyframe(depth[i], interval) {
+				continue
+			}
+
+			// Reconstruction starts at the keyframes of the ancestors added so far
+			lines, err := res.Lines(root, val, i)
+			if err != nil {
+				return result, err
+			}
+			snapshot, err := ob.Write(root, "_base_", []byte(strings.Join(lines, "")))
+			if err != nil {
+				return result, err
+			}
+			val.Versions[i].Snapshot = snapshot
+			depth[i] = 0
+			added++
+		}
+		if added > 0 {
+			tracker[fileId] = val
+			result.Files++
+			result.Keyframes += added
+		}
+	}
+	if result.Keyframes == 0 {
+		return result, nil
+	}
+
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return result, er.TrackerWriteErr
+	}
+	return result, tr.SaveTracker(root, 0, marshalContent)
+}
+
+// Sets after how many versions of a text file commits store a keyframe
+func SetInterval(root string, interval int) error {
+	if interval <= 0 {
+		return er.InvalidInterval
+	}
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return err
+	}
+	meta.KeyframeInterval = interval
+	return tr.SaveMeta(root, meta)
+}
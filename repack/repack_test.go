@@ -0,0 +1,88 @@
+package repack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestRepack tests that keyframes are added to an existing history and stored by later commits
+func TestRepack(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	content := "v0\n"
+	if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	var want []string
+	commit := func(i int) {
+		content += fmt.Sprintf("v%d\n", i)
+		want = append(want, content)
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := cm.CommitUnit(root, "notes.txt", "update"); err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+	}
+	for i := 1; i <= 7; i++ {
+		commit(i)
+	}
+
+	if err := SetInterval(root, 0); err == nil {
+		t.Errorf("expected an error for interval 0")
+	}
+	if err := SetInterval(root, 3); err != nil {
+		t.Fatalf("SetInterval() failed: %v", err)
+	}
+	result, err := Repack(root)
+	if err != nil || result.Files != 1 || result.Keyframes != 2 {
+		t.Fatalf("Repack() = %+v, %v; want 2 keyframes in 1 file", result, err)
+	}
+	if result, err := Repack(root); err != nil || result.Keyframes != 0 {
+		t.Errorf("second Repack() = %+v, %v; want no keyframes", result, err)
+	}
+
+	// Commits store keyframes at the interval themselves
+	commit(8)
+	commit(9)
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val := tracker[utl.Hasher("notes.txt")]
+	// The history is linear, every third version replays three deltas
+	for i, version := range val.Versions {
+		if keyframe := version.Snapshot != ""; keyframe != ((i+1)%3 == 0) {
+			t.Errorf("version %d keyframe = %v", i, keyframe)
+		}
+	}
+
+	// Reconstruction starts at the latest keyframe, older deltas are not needed
+	if err := os.Remove(utl.ObjectPath(root, val.Versions[0].Object())); err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i < len(want); i++ {
+		lines, err := res.Lines(root, val, i)
+		if err != nil {
+			t.Fatalf("Lines(%d) failed: %v", i, err)
+		}
+		if got := strings.Join(lines, ""); got != want[i] {
+			t.Errorf("version %d: expected %q, got %q", i, want[i], got)
+		}
+	}
+}
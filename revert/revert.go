@@ -7,6 +7,7 @@ import (
 
 	// cp "github.com/mainak55512/qwe/compressor"
 	bh "github.com/mainak55512/qwe/binaryhandler"
+	"github.com/mainak55512/qwe/index"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 	rb "github.com/mainak55512/qwe/rebase"
@@ -14,79 +15,89 @@ import (
 	tr "github.com/mainak55512/qwe/tracker"
 )
 
-// Reverts the file to a specific version
-func Revert(commitNumber int, filePath string) error {
+// Reverts the file to a specific version, commitNumber -1 refers to the head of the checked out branch.
+// Returns the commit number the file is reverted to.
+func Revert(root string, commitNumber int, filePath string) (int, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return -1, err
+	}
 
 	// Check if the file is present before reverting
-	if exists := utl.FileExists(filePath); !exists {
-		return fmt.Errorf("%w: %s\nUse 'recover' command to restore '%[2]s' if it was tracked earlier", er.InvalidFile, filePath)
+	if exists := utl.FileExists(utl.WorkPath(root, filePath)); !exists {
+		return -1, fmt.Errorf("%w: %s\nUse 'recover' command to restore '%[2]s' if it was tracked earlier", er.InvalidFile, filePath)
 	}
 
 	// Get tracker details
-	tracker, _, err := tr.GetTracker(0)
+	tracker, _, err := tr.GetTracker(root, 0)
 	if err != nil {
-		return err
+		return -1, err
 	}
 	fileId := utl.Hasher(filePath)
 
 	// Check if the file is tracked
-	if val, ok := tracker[fileId]; ok {
-		// Check if the commit number is valid
-		if commitNumber < -1 || commitNumber > len(val.Versions)-1 {
-			return er.InvalidCommitNo
-		}
-
-		if len(val.Versions) == 0 {
-			return fmt.Errorf("File %s was never committed, use 'rebase' command to revert back to base version", filePath)
-		}
+	val, ok := tracker[fileId]
+	if !ok {
+		return -1, er.FileNotTracked
+	}
 
-		if strings.HasPrefix(val.Base, "_bin_") {
-			commitID := commitNumber
-			if commitNumber == -1 {
-				commitID = len(val.Versions) - 1
-			}
+	// Check if the commit number is valid
+	if commitNumber < -1 || commitNumber > len(val.Versions)-1 {
+		return -1, er.InvalidCommitNo
+	}
 
-			fileObjID := val.Versions[commitID].UID
+	if len(val.Versions) == 0 {
+		return -1, fmt.Errorf("File %s was never committed, use 'rebase' command to revert back to base version", filePath)
+	}
 
-			if err = bh.RevertBinFile(filePath, fileObjID); err != nil {
-				return err
-			}
-		} else {
+	// if commitID is -1 that means revert back to the head of the checked out branch
+	if commitNumber == -1 {
+		if commitNumber = val.CommitNumber(val.BranchHeads()[val.CurrentBranch()]); commitNumber < 0 {
+			return -1, fmt.Errorf("Branch %s of %s is at the base version, use 'rebase' command to revert back to it", val.CurrentBranch(), filePath)
+		}
+	}
 
-			target := filePath
+	if strings.HasPrefix(val.Base, "_bin_") {
+		version := val.Versions[commitNumber]
 
-			// Reconstruct the file till the specific commit number
-			if err = res.Reconstruct(val, target, commitNumber); err != nil {
-				return err
-			}
+		if err = bh.RevertBinFile(root, utl.WorkPath(root, filePath), version.Object(), version.DeltaOf); err != nil {
+			return -1, err
 		}
+	} else {
 
-		// Update the current version of the file in tracker
-		// if commitID is -1 that means reverted back to latest commit
-		if commitNumber == -1 {
-			commitNumber = len(val.Versions) - 1
-		}
-		val.Current = val.Versions[commitNumber].UID
-		tracker[fileId] = val
-		marshalContent, err := json.MarshalIndent(tracker, "", " ")
-		if err != nil {
-			return er.CommitUnsuccessful
-		}
+		target := utl.WorkPath(root, filePath)
 
-		// Update the tracker
-		if err = tr.SaveTracker(0, marshalContent); err != nil {
-			return err
+		// Reconstruct the file till the specific commit number
+		if err = res.Reconstruct(root, val, target, commitNumber); err != nil {
+			return -1, err
 		}
 	}
-	fmt.Println("Successfully reverted", filePath, " back to commit", commitNumber)
-	return nil
+
+	// Update the current version of the file in tracker
+	val.Current = val.Versions[commitNumber].UID
+	tracker[fileId] = val
+	marshalContent, err := json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return -1, er.CommitUnsuccessful
+	}
+
+	// Update the tracker
+	if err = tr.SaveTracker(root, 0, marshalContent); err != nil {
+		return -1, err
+	}
+
+	// The index only speeds up later commits, failing to update it does not fail the revert
+	index.Record(root, fileId, utl.WorkPath(root, filePath), val.Current, val.ContentHash(val.Current))
+	return commitNumber, nil
 }
 
 // Revert a group to any specific version
-func RevertGroup(groupName string, commitID int) error {
+func RevertGroup(root, groupName string, commitID int) error {
 
 	// Get group tracker
-	_, groupTracker, err := tr.GetTracker(1)
+	_, groupTracker, err := tr.GetTracker(root, 1)
 	if err != nil {
 		return err
 	}
@@ -110,11 +121,11 @@ func RevertGroup(groupName string, commitID int) error {
 		commitNumber := files[k].CommitNumber
 
 		if commitNumber >= 0 { // commit number +ve means normal tracked file
-			if err := Revert(commitNumber, files[k].FileName); err != nil {
+			if _, err := Revert(root, commitNumber, files[k].FileName); err != nil {
 				return err
 			}
 		} else if commitNumber == -2 { // commit number -2 means file is just tracked in qwe, no other commits are present, hence need to revert to base version
-			if err := rb.Rebase(files[k].FileName); err != nil {
+			if err := rb.Rebase(root, files[k].FileName); err != nil {
 				return err
 			}
 		}
@@ -132,7 +143,7 @@ func RevertGroup(groupName string, commitID int) error {
 	}
 
 	// Update the tracker
-	if err = tr.SaveTracker(1, marshalContent); err != nil {
+	if err = tr.SaveTracker(root, 1, marshalContent); err != nil {
 		return err
 	}
 	return nil
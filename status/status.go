@@ -0,0 +1,186 @@
+package status
+
+import (
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	bh "github.com/mainak55512/qwe/binaryhandler"
+	dl "github.com/mainak55512/qwe/delta"
+	"github.com/mainak55512/qwe/index"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	res "github.com/mainak55512/qwe/reconstruct"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// State of a file on disk compared to its version in the repository
+type State string
+
+const (
+	Unchanged State = "unchanged"
+	Modified  State = "modified"
+	Deleted   State = "deleted"   // tracked but missing on disk
+	Untracked State = "untracked" // matched by a source of the group but not in the group
+)
+
+// File is empty for files tracked by an earlier version of qwe whose path could not be recovered
+type Entry struct {
+	File   string `json:"file"`
+	FileID string `json:"file_id"`
+	State  State  `json:"state"`
+}
+
+// Compares every tracked file against its current version, entries are sorted by file
+func Status(root string) ([]Entry, error) {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := index.Load(root)
+
+	var entries []Entry
+	for fileId, val := range tracker {
+		// Files without a path were not found on disk when their path was recovered
+		state := Deleted
+		if val.Path != "" {
+			if state, err = compare(root, fileId, val.Path, val, val.Current, ix); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, Entry{File: val.Path, FileID: fileId, State: state})
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Sorts entries by file, files without a path by their id
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].File != entries[j].File {
+			return entries[i].File < entries[j].File
+		}
+		return entries[i].FileID < entries[j].FileID
+	})
+}
+
+// Compares every file of the current group commit against its version in that commit.
+// Files matching the sources of the group that are not in it are reported as untracked.
+func GroupStatus(root, groupName string) ([]Entry, error) {
+	tracker, groupTracker, err := trackers(root)
+	if err != nil {
+		return nil, err
+	}
+	gr, ok := groupTracker[utl.Hasher(groupName)]
+	if !ok {
+		return nil, er.InvalidGroup
+	}
+	current, ok := gr.Versions[gr.Current]
+	if !ok {
+		return nil, er.CurrentGrpErr
+	}
+
+	ix := index.Load(root)
+
+	var entries []Entry
+	for fileId, file := range current.Files {
+		val, ok := tracker[fileId]
+		if !ok {
+			return nil, er.FileNotTracked
+		}
+		versionID := file.FileObjID
+		if versionID == "" {
+			versionID = val.Current
+		}
+		state, err := compare(root, fileId, file.FileName, val, versionID, ix)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{File: file.FileName, FileID: fileId, State: state})
+	}
+
+	seen := make(map[string]bool)
+	for _, source := range gr.Sources {
+		files, err := source.Files(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range files {
+			if _, ok := current.Files[utl.Hasher(filePath)]; !ok && !seen[filePath] {
+				seen[filePath] = true
+				entries = append(entries, Entry{File: filePath, FileID: utl.Hasher(filePath), State: Untracked})
+			}
+		}
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Returns both trackers of the repository
+func trackers(root string) (tr.TrackerSchema, tr.GroupTrackerSchema, error) {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tracker, groupTracker, nil
+}
+
+// Compares the file on disk against a version of it. Files the index knows to be unchanged are not read,
+// otherwise the content hash recorded for the version decides when the file is unchanged.
+// The version is only reconstructed if the hashes differ or none was recorded.
+func compare(root, fileId, filePath string, val tr.Tracker, versionID string, ix index.Index) (State, error) {
+	workPath := utl.WorkPath(root, filePath)
+	if !utl.FileExists(workPath) {
+		return Deleted, nil
+	}
+	if _, ok := ix.Unchanged(fileId, workPath, versionID); ok {
+		return Unchanged, nil
+	}
+	isBin := strings.HasPrefix(val.Base, "_bin_")
+
+	if hash := val.ContentHash(versionID); hash != "" {
+		fileHash, err := utl.FileContentID(workPath)
+		if err != nil {
+			return "", err
+		}
+		if fileHash == hash {
+			return Unchanged, nil
+		}
+		if isBin {
+			return Modified, nil
+		}
+	}
+
+	var same bool
+	if isBin {
+		var err error
+		if same, err = bh.SameAsObject(root, workPath, val.Object(versionID), val.DeltaOf(versionID)); err != nil {
+			return "", err
+		}
+	} else {
+		// Text files compare by lines like commits do
+		commitNumber := val.CommitNumber(versionID)
+		if commitNumber == -3 {
+			return "", er.InvalidCommitNo
+		}
+		lines, err := res.Lines(root, val, commitNumber)
+		if err != nil {
+			return "", err
+		}
+		content, err := os.ReadFile(workPath)
+		if err != nil {
+			return "", err
+		}
+		same = slices.Equal(lines, dl.SplitLines(content))
+	}
+	if same {
+		return Unchanged, nil
+	}
+	return Modified, nil
+}
@@ -0,0 +1,121 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	"github.com/mainak55512/qwe/index"
+	in "github.com/mainak55512/qwe/initializer"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// writeFile creates or replaces a file below root
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// states returns the state of every file of the entries
+func states(entries []Entry) map[string]State {
+	got := make(map[string]State)
+	for _, entry := range entries {
+		got[entry.File] = entry.State
+	}
+	return got
+}
+
+// TestStatus tests the states of tracked text and binary files
+func TestStatus(t *testing.T) {
+	root := t.TempDir()
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	writeFile(t, root, "notes.txt", "a\n")
+	writeFile(t, root, "same.txt", "same\n")
+	writeFile(t, root, "gone.txt", "gone\n")
+	writeFile(t, root, "image.bin", "\x00\x01")
+	if _, err := tr.StartTrackingPaths(root, []string{"notes.txt", "same.txt", "gone.txt", "image.bin"}, false); err != nil {
+		t.Fatalf("StartTrackingPaths() failed: %v", err)
+	}
+	writeFile(t, root, "notes.txt", "a\nb\n")
+	if _, _, err := cm.CommitUnit(root, "notes.txt", "add b"); err != nil {
+		t.Fatalf("CommitUnit() failed: %v", err)
+	}
+
+	writeFile(t, root, "image.bin", "\x00\x02")
+	if err := os.Remove(filepath.Join(root, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Status(root)
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	want := map[string]State{"notes.txt": Unchanged, "same.txt": Unchanged, "gone.txt": Deleted, "image.bin": Modified}
+	got := states(entries)
+	for file, state := range want {
+		if got[file] != state {
+			t.Errorf("state of %s = %q, want %q", file, got[file], state)
+		}
+	}
+
+	// Versions without a recorded hash are compared by their content
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, val := range tracker {
+		if val.Path == "notes.txt" {
+			val.Versions[0].Hash = ""
+			writeFile(t, root, "notes.txt", "a\nc\n")
+			if state, err := compare(root, "", "notes.txt", val, val.Current, index.Index{}); err != nil || state != Modified {
+				t.Errorf("compare() = %q, %v; want modified", state, err)
+			}
+			writeFile(t, root, "notes.txt", "a\nb\n")
+			if state, err := compare(root, "", "notes.txt", val, val.Current, index.Index{}); err != nil || state != Unchanged {
+				t.Errorf("compare() = %q, %v; want unchanged", state, err)
+			}
+		}
+	}
+}
+
+// TestGroupStatus tests that groups compare against their current commit and report new files of their sources
+func TestGroupStatus(t *testing.T) {
+	root := t.TempDir()
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := in.GroupInit(root, "docs"); err != nil {
+		t.Fatalf("GroupInit() failed: %v", err)
+	}
+	writeFile(t, root, "docs/a.md", "a\n")
+	if _, err := tr.StartGroupTracking(root, "docs", []string{"docs"}, false); err != nil {
+		t.Fatalf("StartGroupTracking() failed: %v", err)
+	}
+	if _, err := tr.AddGroupSources(root, "docs", []string{"docs"}, false); err != nil {
+		t.Fatalf("AddGroupSources() failed: %v", err)
+	}
+
+	// Committing the file on its own does not change the group commit
+	writeFile(t, root, "docs/a.md", "a\nb\n")
+	if _, _, err := cm.CommitUnit(root, "docs/a.md", "add b"); err != nil {
+		t.Fatalf("CommitUnit() failed: %v", err)
+	}
+	writeFile(t, root, "docs/new.md", "new\n")
+
+	entries, err := GroupStatus(root, "docs")
+	if err != nil {
+		t.Fatalf("GroupStatus() failed: %v", err)
+	}
+	got := states(entries)
+	if len(got) != 2 || got["docs/a.md"] != Modified || got["docs/new.md"] != Untracked {
+		t.Errorf("GroupStatus() = %v", entries)
+	}
+}
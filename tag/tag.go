@@ -0,0 +1,228 @@
+package tag
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Name given to a commit of a file or a group
+type Tag struct {
+	Name   string `json:"name"`
+	Commit int    `json:"commit"`
+}
+
+// Checks that a tag name can be told apart from commit numbers wherever both are accepted
+func validName(name string) error {
+	if _, err := strconv.Atoi(name); err == nil || name == "" || name == "uncommitted" ||
+		strings.HasPrefix(name, "-") || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return er.InvalidTagName
+	}
+	return nil
+}
+
+// Returns the tracker entry of the file along with its id and the repository metadata
+func fileEntry(root, filePath string) (string, tr.Tracker, tr.Meta, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return "", tr.Tracker{}, tr.Meta{}, err
+	}
+
+	// Get tracker details
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return "", tr.Tracker{}, tr.Meta{}, err
+	}
+	fileId := utl.Hasher(filePath)
+
+	// Check if the file is tracked
+	val, ok := tracker[fileId]
+	if !ok {
+		return "", tr.Tracker{}, tr.Meta{}, er.FileNotTracked
+	}
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return "", tr.Tracker{}, tr.Meta{}, err
+	}
+	return fileId, val, meta, nil
+}
+
+// Resolves a commit number or a tag of the file to its commit number, -2 for the base version
+func Resolve(root, filePath, ref string) (int, error) {
+	fileId, val, meta, err := fileEntry(root, filePath)
+	if err != nil {
+		return -1, err
+	}
+	return meta.Tags.ResolveFile(fileId, val, ref)
+}
+
+// Tags a commit of the file, ref is a commit number or another tag of the file
+func Create(root, filePath, ref, name string) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	fileId, val, meta, err := fileEntry(root, filePath)
+	if err != nil {
+		return err
+	}
+	commitNumber, err := meta.Tags.ResolveFile(fileId, val, ref)
+	if err != nil {
+		return err
+	}
+	if commitNumber < 0 || commitNumber > len(val.Versions)-1 {
+		return er.InvalidCommitNo
+	}
+	if _, ok := meta.Tags.File(fileId, name); ok {
+		return er.TagExists
+	}
+
+	if meta.Tags.Files == nil {
+		meta.Tags.Files = make(map[string]map[string]string)
+	}
+	if meta.Tags.Files[fileId] == nil {
+		meta.Tags.Files[fileId] = make(map[string]string)
+	}
+	meta.Tags.Files[fileId][name] = val.Versions[commitNumber].UID
+	return tr.SaveMeta(root, meta)
+}
+
+// Deletes a tag of the file, the tagged commit stays
+func Delete(root, filePath, name string) error {
+	fileId, _, meta, err := fileEntry(root, filePath)
+	if err != nil {
+		return err
+	}
+	if _, ok := meta.Tags.File(fileId, name); !ok {
+		return er.InvalidTag
+	}
+	delete(meta.Tags.Files[fileId], name)
+	if len(meta.Tags.Files[fileId]) == 0 {
+		delete(meta.Tags.Files, fileId)
+	}
+	return tr.SaveMeta(root, meta)
+}
+
+// Returns the tags of the file ordered by commit and name
+func List(root, filePath string) ([]Tag, error) {
+	fileId, val, meta, err := fileEntry(root, filePath)
+	if err != nil {
+		return nil, err
+	}
+	var tags []Tag
+	for name, versionID := range meta.Tags.Files[fileId] {
+		tags = append(tags, Tag{Name: name, Commit: val.CommitNumber(versionID)})
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+// Returns the entry of the group along with its id and the repository metadata
+func groupEntry(root, groupName string) (string, tr.GroupTracker, tr.Meta, error) {
+
+	// Get group tracker
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return "", tr.GroupTracker{}, tr.Meta{}, err
+	}
+	groupId := utl.Hasher(groupName)
+
+	// Check if valid group
+	gr, ok := groupTracker[groupId]
+	if !ok {
+		return "", tr.GroupTracker{}, tr.Meta{}, er.InvalidGroup
+	}
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return "", tr.GroupTracker{}, tr.Meta{}, err
+	}
+	return groupId, gr, meta, nil
+}
+
+// Resolves a commit number or a tag of the group to its commit number
+func GroupResolve(root, groupName, ref string) (int, error) {
+	groupId, gr, meta, err := groupEntry(root, groupName)
+	if err != nil {
+		return -1, err
+	}
+	return meta.Tags.ResolveGroup(groupId, gr, ref)
+}
+
+// Tags a commit of the group, ref is a commit number or another tag of the group
+func GroupCreate(root, groupName, ref, name string) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	groupId, gr, meta, err := groupEntry(root, groupName)
+	if err != nil {
+		return err
+	}
+	commitID, err := meta.Tags.ResolveGroup(groupId, gr, ref)
+	if err != nil {
+		return err
+	}
+	if commitID < 0 || commitID > len(gr.VersionOrder)-1 {
+		return er.InvalidCommitNo
+	}
+	if _, ok := meta.Tags.Group(groupId, name); ok {
+		return er.TagExists
+	}
+
+	if meta.Tags.Groups == nil {
+		meta.Tags.Groups = make(map[string]map[string]string)
+	}
+	if meta.Tags.Groups[groupId] == nil {
+		meta.Tags.Groups[groupId] = make(map[string]string)
+	}
+	meta.Tags.Groups[groupId][name] = gr.VersionOrder[commitID]
+	return tr.SaveMeta(root, meta)
+}
+
+// Deletes a tag of the group, the tagged commit stays
+func GroupDelete(root, groupName, name string) error {
+	groupId, _, meta, err := groupEntry(root, groupName)
+	if err != nil {
+		return err
+	}
+	if _, ok := meta.Tags.Group(groupId, name); !ok {
+		return er.InvalidTag
+	}
+	delete(meta.Tags.Groups[groupId], name)
+	if len(meta.Tags.Groups[groupId]) == 0 {
+		delete(meta.Tags.Groups, groupId)
+	}
+	return tr.SaveMeta(root, meta)
+}
+
+// Returns the tags of the group ordered by commit and name
+func GroupList(root, groupName string) ([]Tag, error) {
+	groupId, gr, meta, err := groupEntry(root, groupName)
+	if err != nil {
+		return nil, err
+	}
+	var tags []Tag
+	for name := range meta.Tags.Groups[groupId] {
+		commitID, err := meta.Tags.ResolveGroup(groupId, gr, name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, Tag{Name: name, Commit: commitID})
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func sortTags(tags []Tag) {
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Commit != tags[j].Commit {
+			return tags[i].Commit < tags[j].Commit
+		}
+		return tags[i].Name < tags[j].Name
+	})
+}
@@ -0,0 +1,130 @@
+package tag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	er "github.com/mainak55512/qwe/qwerror"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// TestCreate tests that tags resolve to the commits they were given to and stay apart from commit numbers
+func TestCreate(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v0\n")
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	for _, content := range []string{"v1\n", "v2\n"} {
+		write(content)
+		if _, _, err := cm.CommitUnit(root, "notes.txt", content); err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+	}
+
+	for _, name := range []string{"1", "-rc", "bad name", "uncommitted"} {
+		if err := Create(root, "notes.txt", "0", name); !errors.Is(err, er.InvalidTagName) {
+			t.Errorf("Create(%q): expected InvalidTagName, got %v", name, err)
+		}
+	}
+	if err := Create(root, "notes.txt", "2", "v2"); !errors.Is(err, er.InvalidCommitNo) {
+		t.Errorf("expected InvalidCommitNo, got %v", err)
+	}
+	if err := Create(root, "notes.txt", "0", "v1.0"); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := Create(root, "notes.txt", "v1.0", "first"); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := Create(root, "notes.txt", "1", "first"); !errors.Is(err, er.TagExists) {
+		t.Errorf("expected TagExists, got %v", err)
+	}
+	if err := Create(root, "notes.txt", "1", "v2.0"); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	if commitNumber, err := Resolve(root, "notes.txt", "v2.0"); err != nil || commitNumber != 1 {
+		t.Errorf("Resolve() = %d, %v; want 1, nil", commitNumber, err)
+	}
+	if commitNumber, err := Resolve(root, "notes.txt", "0"); err != nil || commitNumber != 0 {
+		t.Errorf("Resolve() = %d, %v; want 0, nil", commitNumber, err)
+	}
+	if _, err := Resolve(root, "notes.txt", "v3.0"); !errors.Is(err, er.InvalidTag) {
+		t.Errorf("expected InvalidTag, got %v", err)
+	}
+
+	if err := Delete(root, "notes.txt", "first"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := Delete(root, "notes.txt", "first"); !errors.Is(err, er.InvalidTag) {
+		t.Errorf("expected InvalidTag, got %v", err)
+	}
+	tags, err := List(root, "notes.txt")
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	want := []Tag{{Name: "v1.0", Commit: 0}, {Name: "v2.0", Commit: 1}}
+	if len(tags) != len(want) || tags[0] != want[0] || tags[1] != want[1] {
+		t.Errorf("List() = %+v; want %+v", tags, want)
+	}
+}
+
+// TestGroupCreate tests that group tags resolve to the group commits they were given to
+func TestGroupCreate(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := in.GroupInit(root, "docs"); err != nil {
+		t.Fatalf("GroupInit() failed: %v", err)
+	}
+	if err := os.WriteFile(notes, []byte("v0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.StartGroupTracking(root, "docs", []string{"notes.txt"}, false); err != nil {
+		t.Fatalf("StartGroupTracking() failed: %v", err)
+	}
+	for _, content := range []string{"v1\n", "v2\n"} {
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := cm.CommitGroup(root, "docs", content); err != nil {
+			t.Fatalf("CommitGroup() failed: %v", err)
+		}
+	}
+
+	if err := GroupCreate(root, "docs", "1", "release"); err != nil {
+		t.Fatalf("GroupCreate() failed: %v", err)
+	}
+	if err := GroupCreate(root, "docs", "1", "release"); !errors.Is(err, er.TagExists) {
+		t.Errorf("expected TagExists, got %v", err)
+	}
+	if commitID, err := GroupResolve(root, "docs", "release"); err != nil || commitID != 1 {
+		t.Errorf("GroupResolve() = %d, %v; want 1, nil", commitID, err)
+	}
+	tags, err := GroupList(root, "docs")
+	if err != nil || len(tags) != 1 || tags[0] != (Tag{Name: "release", Commit: 1}) {
+		t.Errorf("GroupList() = %+v, %v", tags, err)
+	}
+	if err := GroupDelete(root, "docs", "release"); err != nil {
+		t.Fatalf("GroupDelete() failed: %v", err)
+	}
+	if _, err := GroupResolve(root, "docs", "release"); !errors.Is(err, er.InvalidTag) {
+		t.Errorf("expected InvalidTag, got %v", err)
+	}
+}
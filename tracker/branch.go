@@ -0,0 +1,68 @@
+package tracker
+
+// Branch files and groups are on until another branch is created and switched to
+const DefaultBranch = "main"
+
+// Returns the name of the checked out branch of the file
+func (t Tracker) CurrentBranch() string {
+	if t.Branch == "" {
+		return DefaultBranch
+	}
+	return t.Branch
+}
+
+// Returns the head of every branch of the file by name, either Base or a version UID.
+// Files that never had a branch created only have the default branch, its head is the latest version.
+func (t Tracker) BranchHeads() map[string]string {
+	heads := make(map[string]string)
+	if len(t.Branches) == 0 {
+		heads[DefaultBranch] = t.Base
+		if len(t.Versions) > 0 {
+			heads[DefaultBranch] = t.Versions[len(t.Versions)-1].UID
+		}
+		return heads
+	}
+	for name, head := range t.Branches {
+		heads[name] = head
+	}
+	return heads
+}
+
+// Moves the head of the checked out branch to a new version of the file
+func (t *Tracker) AdvanceBranch(versionID string) {
+	if len(t.Branches) > 0 {
+		t.Branches[t.CurrentBranch()] = versionID
+	}
+}
+
+// Returns the name of the checked out branch of the group
+func (g GroupTracker) CurrentBranch() string {
+	if g.Branch == "" {
+		return DefaultBranch
+	}
+	return g.Branch
+}
+
+// Returns the head of every branch of the group by name, the key of a group commit.
+// Groups that never had a branch created only have the default branch, its head is the latest commit.
+func (g GroupTracker) BranchHeads() map[string]string {
+	heads := make(map[string]string)
+	if len(g.Branches) == 0 {
+		heads[DefaultBranch] = g.Current
+		if len(g.VersionOrder) > 0 {
+			heads[DefaultBranch] = g.VersionOrder[len(g.VersionOrder)-1]
+		}
+		return heads
+	}
+	for name, head := range g.Branches {
+		heads[name] = head
+	}
+	return heads
+}
+
+// Moves the head of the checked out branch to a new commit of the group
+func (g *GroupTracker) AdvanceBranch(versionID string) {
+	if len(g.Branches) > 0 {
+		g.Branches[g.CurrentBranch()] = versionID
+	}
+}
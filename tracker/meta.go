@@ -0,0 +1,67 @@
+package tracker
+
+import (
+	"encoding/json"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// Version of the repository layout written by this version of qwe,
+// repositories with an older version are upgraded by the migrate package
+const SchemaVersion = 8
+
+// Number of versions of a text file after which a full snapshot is stored unless configured otherwise
+const DefaultKeyframeInterval = 50
+
+// Repository wide details stored in _meta.qwe
+type Meta struct {
+	SchemaVersion    int  `json:"schema_version"`
+	KeyframeInterval int  `json:"keyframe_interval,omitempty"`
+	Tags             Tags `json:"tags"`
+}
+
+// Returns after how many versions of a text file a full snapshot of it is stored
+func (m Meta) Keyframes() int {
+	if m.KeyframeInterval <= 0 {
+		return DefaultKeyframeInterval
+	}
+	return m.KeyframeInterval
+}
+
+// Reports whether a version whose reconstruction replays chainLength deltas, see Tracker.DeltaChain,
+// is to be stored as a keyframe under the interval
+func IsKeyframe(chainLength, interval int) bool {
+	return chainLength >= interval
+}
+
+// Returns the repository metadata, repositories created before _meta.qwe existed are at schema version 1
+func GetMeta(root string) (Meta, error) {
+	meta := Meta{SchemaVersion: 1}
+
+	metaPath, err := trackerPath(root, 2)
+	if err != nil {
+		return meta, err
+	}
+	if !utl.FileExists(metaPath) {
+		return meta, nil
+	}
+
+	content, err := readTrackerFile(metaPath)
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(content, &meta); err != nil {
+		return meta, er.TrackerParseErr
+	}
+	return meta, nil
+}
+
+// Updates _meta.qwe file
+func SaveMeta(root string, meta Meta) error {
+	marshalContent, err := json.MarshalIndent(meta, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	return SaveTracker(root, 2, marshalContent)
+}
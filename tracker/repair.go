@@ -0,0 +1,95 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cp "github.com/mainak55512/qwe/compressor"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// Returns the JSON content of a tracker file, whether it is compressed or was left
+// uncompressed by an interrupted command of an older version of qwe.
+// The second value reports if the file was compressed.
+func loadTrackerFile(path string) ([]byte, bool, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, false
+	}
+	if decompressed, err := cp.Decompress(content); err == nil && json.Valid(decompressed) {
+		return decompressed, true, true
+	}
+	if len(content) > 0 && json.Valid(content) {
+		return content, false, true
+	}
+	return nil, false, false
+}
+
+// Reports whether an interrupted command left a tracker file half-written or uncompressed, see Repair.
+// It only reads the trackers, so commands can check it without waiting for the repository lock.
+func NeedsRepair(root string) (bool, error) {
+	for trackerType := 0; trackerType <= 2; trackerType++ {
+		path, err := trackerPath(root, trackerType)
+		if err != nil {
+			return false, err
+		}
+		if utl.FileExists(utl.TempPath(path)) {
+			return true, nil
+		}
+		if _, compressed, ok := loadTrackerFile(path); !ok || !compressed {
+			// Repositories created before _meta.qwe existed do not have it
+			if trackerType == 2 && !utl.FileExists(path) {
+				continue
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Detects and repairs tracker files left half-written by an interrupted command.
+// Unfinished writes are discarded when the tracker itself is intact and used to restore it otherwise,
+// uncompressed trackers are compressed again. Returns the descriptions of the repairs made.
+func Repair(root string) ([]string, error) {
+	var repairs []string
+	for trackerType := 0; trackerType <= 2; trackerType++ {
+		path, err := trackerPath(root, trackerType)
+		if err != nil {
+			return repairs, err
+		}
+		name := filepath.Base(path)
+		tmpPath := utl.TempPath(path)
+
+		content, compressed, ok := loadTrackerFile(path)
+		switch {
+		case ok && compressed:
+			if !utl.FileExists(tmpPath) {
+				continue
+			}
+			repairs = append(repairs, fmt.Sprintf("discarded unfinished write of %s", name))
+		case ok:
+			if err := SaveTracker(root, trackerType, content); err != nil {
+				return repairs, err
+			}
+			repairs = append(repairs, fmt.Sprintf("compressed %s again", name))
+		default:
+			tmpContent, _, tmpOk := loadTrackerFile(tmpPath)
+			if !tmpOk {
+				// Repositories created before _meta.qwe existed do not have it
+				if trackerType == 2 && !utl.FileExists(path) {
+					continue
+				}
+				return repairs, fmt.Errorf("%w: %s", er.TrackerCorrupted, name)
+			}
+			if err := SaveTracker(root, trackerType, tmpContent); err != nil {
+				return repairs, err
+			}
+			repairs = append(repairs, fmt.Sprintf("restored %s from its unfinished write", name))
+		}
+		os.Remove(tmpPath)
+	}
+	return repairs, nil
+}
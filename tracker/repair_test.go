@@ -0,0 +1,91 @@
+package tracker
+
+import (
+	"os"
+	"testing"
+
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// newRepo creates the tracker files of an empty repository
+func newRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(utl.QwePath(root, "_object"), 0755); err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	for trackerType := 0; trackerType <= 1; trackerType++ {
+		if err := SaveTracker(root, trackerType, []byte("{}")); err != nil {
+			t.Fatalf("SaveTracker(%d) failed: %v", trackerType, err)
+		}
+	}
+	return root
+}
+
+// TestGetTracker_ReadOnly tests that reading a tracker leaves the file untouched
+func TestGetTracker_ReadOnly(t *testing.T) {
+	root := newRepo(t)
+	path := utl.QwePath(root, "_tracker.qwe")
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat tracker: %v", err)
+	}
+	if _, _, err := GetTracker(root, 0); err != nil {
+		t.Fatalf("GetTracker() failed: %v", err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat tracker: %v", err)
+	}
+	if !before.ModTime().Equal(after.ModTime()) || utl.FileExists(utl.TempPath(path)) {
+		t.Errorf("GetTracker() modified the tracker file")
+	}
+}
+
+// TestRepair tests the recovery from the states an interrupted command can leave behind
+func TestRepair(t *testing.T) {
+	root := newRepo(t)
+	trackerFile := utl.QwePath(root, "_tracker.qwe")
+	groupFile := utl.QwePath(root, "_group_tracker.qwe")
+
+	// Nothing to repair in a healthy repository
+	if repairs, err := Repair(root); err != nil || len(repairs) != 0 {
+		t.Fatalf("Repair() = %v, %v; want no repairs", repairs, err)
+	}
+
+	// Uncompressed tracker left by an interrupted in-place compression
+	if err := os.WriteFile(trackerFile, []byte(`{"a":{"base":"b","current":"b","versions":[]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Truncated group tracker next to a complete unfinished write
+	if err := os.WriteFile(groupFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(utl.TempPath(groupFile), []byte(`{"g":{"group_name":"g"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repairs, err := Repair(root)
+	if err != nil || len(repairs) != 2 {
+		t.Fatalf("Repair() = %v, %v; want 2 repairs", repairs, err)
+	}
+	tracker, _, err := GetTracker(root, 0)
+	if err != nil || tracker["a"].Base != "b" {
+		t.Errorf("tracker not repaired: %v, %v", tracker, err)
+	}
+	_, groupTracker, err := GetTracker(root, 1)
+	if err != nil || groupTracker["g"].GroupName != "g" {
+		t.Errorf("group tracker not restored: %v, %v", groupTracker, err)
+	}
+	if utl.FileExists(utl.TempPath(groupFile)) {
+		t.Errorf("temporary file was not removed")
+	}
+
+	// A corrupted tracker without an unfinished write can not be repaired
+	if err := os.WriteFile(trackerFile, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Repair(root); err == nil {
+		t.Errorf("expected an error for a corrupted tracker")
+	}
+}
@@ -0,0 +1,57 @@
+package tracker
+
+import (
+	"strconv"
+
+	er "github.com/mainak55512/qwe/qwerror"
+)
+
+// Names given to file versions and group commits, see Meta
+type Tags struct {
+	Files  map[string]map[string]string `json:"files,omitempty"`  // by file id, tag names to Base or a version UID
+	Groups map[string]map[string]string `json:"groups,omitempty"` // by group id, tag names to group commits
+}
+
+// Returns the version of the file tagged with the name
+func (t Tags) File(fileId, name string) (string, bool) {
+	versionID, ok := t.Files[fileId][name]
+	return versionID, ok
+}
+
+// Returns the group commit tagged with the name
+func (t Tags) Group(groupId, name string) (string, bool) {
+	versionID, ok := t.Groups[groupId][name]
+	return versionID, ok
+}
+
+// Resolves a commit number or a tag of the file to its commit number, -2 for the base version
+func (t Tags) ResolveFile(fileId string, val Tracker, ref string) (int, error) {
+	if commitNumber, err := strconv.Atoi(ref); err == nil {
+		return commitNumber, nil
+	}
+	versionID, ok := t.File(fileId, ref)
+	if !ok {
+		return -1, er.InvalidTag
+	}
+	if commitNumber := val.CommitNumber(versionID); commitNumber != -3 {
+		return commitNumber, nil
+	}
+	return -1, er.InvalidCommitNo
+}
+
+// Resolves a commit number or a tag of the group to its commit number
+func (t Tags) ResolveGroup(groupId string, gr GroupTracker, ref string) (int, error) {
+	if commitNumber, err := strconv.Atoi(ref); err == nil {
+		return commitNumber, nil
+	}
+	versionID, ok := t.Group(groupId, ref)
+	if !ok {
+		return -1, er.InvalidTag
+	}
+	for i, e := range gr.VersionOrder {
+		if e == versionID {
+			return i, nil
+		}
+	}
+	return -1, er.InvalidCommitNo
+}
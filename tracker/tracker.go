@@ -1,31 +1,159 @@
 package tracker
 
 import (
-	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"time"
+	"slices"
+	"strings"
 
 	bh "github.com/mainak55512/qwe/binaryhandler"
 	cp "github.com/mainak55512/qwe/compressor"
+	ob "github.com/mainak55512/qwe/object"
 	er "github.com/mainak55512/qwe/qwerror"
 	utl "github.com/mainak55512/qwe/qweutils"
 )
 
+// UID identifies the version, ObjID names the object holding its content
 type VersionDetails struct {
 	UID           string `json:"uid"`
+	ObjID         string `json:"obj_id,omitempty"`
+	Parent        string `json:"parent,omitempty"`   // version the commit was made on top of, the base object or a version UID
+	Hash          string `json:"hash,omitempty"`     // content hash of the file, missing for versions committed before it was recorded
+	Snapshot      string `json:"snapshot,omitempty"` // object holding the full content of text versions that are keyframes
+	DeltaOf       string `json:"delta_of,omitempty"` // full copy the object of a binary version is a delta of
 	CommitMessage string `json:"commit_message"`
 	TimeStamp     string `json:"time_stamp"`
 }
 
+// Returns the object holding the content of the version,
+// versions committed before content addressing are stored in an object named after their UID
+func (v VersionDetails) Object() string {
+	if v.ObjID == "" {
+		return v.UID
+	}
+	return v.ObjID
+}
+
+// Move of a tracked file, made once Commits versions had been committed
+type RenameDetails struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Commits   int    `json:"commits"`
+	TimeStamp string `json:"time_stamp"`
+}
+
+// Base is the object of the base version, Current is either Base or the UID of the checked out version.
+// Path is the canonical path of the file, the entry is keyed by its hash.
+// Branches holds the head of every branch once one was created, see BranchHeads.
 type Tracker struct {
-	Base     string           `json:"base"`
-	Current  string           `json:"current"`
-	Versions []VersionDetails `json:"versions"`
+	Path     string            `json:"path,omitempty"`
+	Base     string            `json:"base"`
+	Current  string            `json:"current"`
+	Versions []VersionDetails  `json:"versions"`
+	Renames  []RenameDetails   `json:"renames,omitempty"`
+	Branch   string            `json:"branch,omitempty"`
+	Branches map[string]string `json:"branches,omitempty"`
+}
+
+// Returns the commit number of a version, -2 for the base version and -3 if there is no such version
+func (t Tracker) CommitNumber(versionID string) int {
+	if versionID == t.Base {
+		return -2
+	}
+	for i, version := range t.Versions {
+		if version.UID == versionID {
+			return i
+		}
+	}
+	return -3
+}
+
+// Returns the commit number of the version a commit was made on top of, -2 for the base version
+// and -3 if there is no such commit or its parent is unknown.
+// Versions committed before parents were recorded are on top of the previous version.
+func (t Tracker) Parent(commitNumber int) int {
+	if commitNumber < 0 || commitNumber >= len(t.Versions) {
+		return -3
+	}
+	parent := t.Versions[commitNumber].Parent
+	if parent == "" {
+		if commitNumber == 0 {
+			return -2
+		}
+		return commitNumber - 1
+	}
+	return t.CommitNumber(parent)
+}
+
+// Returns the object a text version is reconstructed from and the commit numbers of the deltas to apply on it,
+// oldest first. Reconstruction starts at the nearest keyframe among the version and its ancestors,
+// or at the base version, commitNumber -2 refers to the base version itself.
+func (t Tracker) DeltaChain(commitNumber int) (string, []int, error) {
+	var chain []int
+	for commit := commitNumber; commit != -2; {
+		if commit < 0 || commit >= len(t.Versions) {
+			return "", nil, er.BrokenHistory
+		}
+		if snapshot := t.Versions[commit].Snapshot; snapshot != "" {
+			slices.Reverse(chain)
+			return snapshot, chain, nil
+		}
+		chain = append(chain, commit)
+
+		// Parents are committed before their children, anything else would loop
+		parent := t.Parent(commit)
+		if parent >= commit {
+			return "", nil, er.BrokenHistory
+		}
+		commit = parent
+	}
+	slices.Reverse(chain)
+	return t.Base, chain, nil
+}
+
+// Returns the hash of the content of the file at a version, as computed by utl.ContentID,
+// or "" if it is unknown because the version was committed by an earlier version of qwe
+func (t Tracker) ContentHash(versionID string) string {
+	if versionID == t.Base {
+		if utl.IsContentID(t.Base) {
+			return utl.ObjectHash(t.Base)
+		}
+		return ""
+	}
+	for _, version := range t.Versions {
+		if version.UID == versionID {
+			if version.Hash == "" && strings.HasPrefix(version.ObjID, "_bin_") && utl.IsContentID(version.ObjID) {
+				return utl.ObjectHash(version.ObjID)
+			}
+			return version.Hash
+		}
+	}
+	return ""
+}
+
+// Returns the full copy the object of a binary version is a delta of, empty if the object is a full copy
+func (t Tracker) DeltaOf(versionID string) string {
+	for _, version := range t.Versions {
+		if version.UID == versionID {
+			return version.DeltaOf
+		}
+	}
+	return ""
+}
+
+// Returns the object holding the content of a version, versionID is either Base or a version UID
+func (t Tracker) Object(versionID string) string {
+	for _, version := range t.Versions {
+		if version.UID == versionID {
+			return version.Object()
+		}
+	}
+	return versionID
 }
 
 type FileDetails struct {
@@ -35,6 +163,7 @@ type FileDetails struct {
 }
 
 type GroupVersionDetails struct {
+	Parent        string                 `json:"parent,omitempty"` // group commit this one was made on top of
 	CommitMessage string                 `json:"commit_message"`
 	Files         map[string]FileDetails `json:"files"`
 }
@@ -44,254 +173,406 @@ type GroupTracker struct {
 	Current      string                         `json:"current"`
 	VersionOrder []string                       `json:"version_order"`
 	Versions     map[string]GroupVersionDetails `json:"versions"`
+	Sources      []GroupSource                  `json:"sources,omitempty"`
+	Branch       string                         `json:"branch,omitempty"`
+	Branches     map[string]string              `json:"branches,omitempty"` // head of every branch by name, see BranchHeads
+}
+
+// Folder or glob pattern a group picks up new files from when it is committed
+type GroupSource struct {
+	Path      string `json:"path"` // canonical folder, "." for the root, or canonical glob pattern
+	Pattern   bool   `json:"pattern,omitempty"`
+	Recursive bool   `json:"recursive,omitempty"`
 }
 
 type TrackerSchema map[string]Tracker
 type GroupTrackerSchema map[string]GroupTracker
 
+// Returns the path of _tracker.qwe, _group_tracker.qwe or _meta.qwe of the repository at root
+func trackerPath(root string, trackerType int) (string, error) {
+
+	// 0 is associated with file tracker, 1 is associated with group tracker, 2 with repository metadata
+	if trackerType == 0 {
+		return utl.QwePath(root, "_tracker.qwe"), nil
+	} else if trackerType == 1 {
+		return utl.QwePath(root, "_group_tracker.qwe"), nil
+	} else if trackerType == 2 {
+		return utl.QwePath(root, "_meta.qwe"), nil
+	}
+	return "", er.InvalidTracker
+}
+
+// Returns the uncompressed content of a tracker file, the file is decompressed in memory only
+func readTrackerFile(trackerPath string) ([]byte, error) {
+	content, err := os.ReadFile(trackerPath)
+	if err != nil {
+		return nil, er.TrackerAccessErr
+	}
+	return cp.Decompress(content)
+}
+
 // Returns the tracker details from _tracker.qwe or _group_tracker.qwe
-func GetTracker(trackerType int) (TrackerSchema, GroupTrackerSchema, error) {
+func GetTracker(root string, trackerType int) (TrackerSchema, GroupTrackerSchema, error) {
 	var tracker_schema TrackerSchema
 	var group_tracker_schema GroupTrackerSchema
 
-	var trackerPath string
-
-	// 0 is associated with file tracker, 1 is associated with group tracker
-	if trackerType == 0 {
-		trackerPath = ".qwe/_tracker.qwe"
-	} else if trackerType == 1 {
-		trackerPath = ".qwe/_group_tracker.qwe"
-	} else {
+	if trackerType != 0 && trackerType != 1 {
 		return nil, nil, er.InvalidTracker
 	}
-
-	// Decompress _tracker.qwe
-	if err := cp.DecompressFile(trackerPath); err != nil {
+	trackerPath, err := trackerPath(root, trackerType)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	file, err := os.Open(trackerPath)
+	current_tracker, err := readTrackerFile(trackerPath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	reader := bufio.NewReader(file)
-	current_tracker, err := io.ReadAll(reader)
-	if err != nil {
-		file.Close()
-		return nil, nil, er.TrackerAccessErr
+	if trackerType == 0 {
+		// Parse the content of the tracker file
+		if err := json.Unmarshal(current_tracker, &tracker_schema); err != nil {
+			return nil, nil, er.TrackerParseErr
+		}
 	} else {
-
-		if trackerType == 0 {
-			// Parse the content of the tracker file
-			if err := json.Unmarshal(current_tracker, &tracker_schema); err != nil {
-				file.Close()
-				cp.CompressFile(trackerPath)
-				return nil, nil, er.TrackerParseErr
-			}
-		} else {
-			// Parse the content of the tracker file
-			if err := json.Unmarshal(current_tracker, &group_tracker_schema); err != nil {
-				file.Close()
-				cp.CompressFile(trackerPath)
-				return nil, nil, er.TrackerParseErr
-			}
+		// Parse the content of the tracker file
+		if err := json.Unmarshal(current_tracker, &group_tracker_schema); err != nil {
+			return nil, nil, er.TrackerParseErr
 		}
 	}
-	file.Close()
-
-	// Compress the tracker
-	if err = cp.CompressFile(trackerPath); err != nil {
-		return nil, nil, err
-	}
 	return tracker_schema, group_tracker_schema, nil
 }
 
-// Updates _tracker.qwe file
-func SaveTracker(trackerType int, content []byte) error {
+// Updates _tracker.qwe, _group_tracker.qwe or _meta.qwe file.
+// The compressed content replaces the file atomically, a crash leaves either the old or the new tracker.
+func SaveTracker(root string, trackerType int, content []byte) error {
 
-	var trackerPath string
-
-	// 0 is associated with file tracker, 1 is associated with group tracker
-	if trackerType == 0 {
-		trackerPath = ".qwe/_tracker.qwe"
-	} else if trackerType == 1 {
-		trackerPath = ".qwe/_group_tracker.qwe"
-	} else {
-		return er.InvalidTracker
-	}
-
-	// Truncate the tracker file
-	tracker_content, err := os.Create(trackerPath)
+	trackerPath, err := trackerPath(root, trackerType)
 	if err != nil {
 		return err
 	}
 
-	// Write the new content on the tracker file
-	writer := bufio.NewWriter(tracker_content)
-	_, err = writer.Write(content)
+	compressed, err := cp.Compress(content)
 	if err != nil {
-		return er.BaseWriteErr
+		return err
 	}
-	if err = writer.Flush(); err != nil {
+	if err = utl.WriteFileAtomic(trackerPath, compressed, 0644); err != nil {
 		return er.TrackerWriteErr
 	}
-	tracker_content.Close()
-
-	// Compress the tracker file
-	if err = cp.CompressFile(trackerPath); err != nil {
-		return err
-	}
 	return nil
 }
 
 // Creates an entry for the file in Tracker and generates a base varient of the file
-func StartTracking(filePath string) (string, error) {
+func StartTracking(root, filePath string) (string, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return "", err
+	}
 
 	// Get tracker details
-	tracker, _, err := GetTracker(0)
+	tracker, _, err := GetTracker(root, 0)
 	if err != nil {
 		return "", er.InvalidFile
 	}
 
-	fileId := utl.Hasher(filePath)
+	fileObjectId, err := addFile(root, tracker, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Update the tracker
+	if err = saveFileTracker(root, tracker); err != nil {
+		return "", err
+	}
+	return fileObjectId, nil
+}
+
+// Starts tracking every file given by paths, which may be files, folders or glob patterns.
+// Files of folders are tracked recursively only if recursive is set. Files found in folders or by patterns
+// that are already tracked are skipped, an explicitly named file that is already tracked is an error.
+// Returns the canonical paths of the newly tracked files.
+func StartTrackingPaths(root string, paths []string, recursive bool) ([]string, error) {
+	matches, err := expandPaths(root, paths, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get tracker details
+	tracker, _, err := GetTracker(root, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var trackedFiles []string
+	for _, m := range matches {
+		if _, ok := tracker[utl.Hasher(m.path)]; ok && !m.explicit {
+			continue
+		}
+		if _, err := addFile(root, tracker, m.path); err != nil {
+			return nil, err
+		}
+		trackedFiles = append(trackedFiles, m.path)
+	}
+
+	// Update the tracker
+	if err = saveFileTracker(root, tracker); err != nil {
+		return nil, err
+	}
+	return trackedFiles, nil
+}
 
-	// This will be used as the name of the base file
-	fileObjectId := "_base_" + utl.Hasher(fmt.Sprintf("%s%d", filePath, time.Now().UnixNano()))
+// Adds an entry for the file to the tracker and stores its base version, filePath is canonical.
+// Returns the ID of the base object.
+func addFile(root string, tracker TrackerSchema, filePath string) (string, error) {
+	fileId := utl.Hasher(filePath)
 
-	isBin, err := bh.CheckBinFile(filePath)
+	isBin, err := bh.CheckBinFile(utl.WorkPath(root, filePath))
 	if err != nil {
 		return "", err
 	}
 
 	// If the file is already tracked then return error
 	if _, ok := tracker[fileId]; ok {
-		return "", er.FileTracked
+		return "", fmt.Errorf("%w: %s", er.FileTracked, filePath)
 	}
 
+	// The base object is named after its content
+	var fileObjectId string
 	if isBin {
 		// return "", er.BinFileErr
-		src, err := os.Open(filePath)
-		if err != nil {
-			return "", err
-		}
-		fileObjectId = "_bin_" + utl.Hasher(fmt.Sprintf("%s%d", filePath, time.Now().UnixNano()))
-		target := ".qwe/_object/" + fileObjectId
-		dest, err := os.Create(target)
+		src, err := os.Open(utl.WorkPath(root, filePath))
 		if err != nil {
 			return "", err
 		}
-		if _, err := io.Copy(dest, src); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
-			return "", err
-		}
-		if err = cp.CompressFile(target); err != nil {
+		defer src.Close()
+		if fileObjectId, err = ob.WriteFrom(root, "_bin_", src); err != nil {
 			return "", err
 		}
 	} else {
 
-		base_content, err := os.ReadFile(filePath)
+		base_content, err := os.ReadFile(utl.WorkPath(root, filePath))
 		if err != nil {
 			return "", fmt.Errorf("File not found: %s", filePath)
 		}
 
-		// (Need to change to a buffered writer) write the content of the file to the base varient
-		if err := os.WriteFile(".qwe/_object/"+fileObjectId, base_content, 0644); err != nil {
+		// Write the compressed content of the file to the base varient
+		if fileObjectId, err = ob.Write(root, "_base_", base_content); err != nil {
 			return "", er.TrackUnsuccessful
 		}
-
-		// Compress the base file
-		if err = cp.CompressFile(".qwe/_object/" + fileObjectId); err != nil {
-			return "", err
-		}
 	}
 
 	// Add tracker entry for the file
 	tracker[fileId] = Tracker{
+		Path:     filePath,
 		Base:     fileObjectId,
 		Current:  fileObjectId,
 		Versions: []VersionDetails{},
 	}
+	return fileObjectId, nil
+}
 
+// Saves the file tracker
+func saveFileTracker(root string, tracker TrackerSchema) error {
 	marshalContent, err := json.MarshalIndent(tracker, "", " ")
 	if err != nil {
-		return "", er.CommitUnsuccessful
-	}
-
-	// Update the tracker
-	if err = SaveTracker(0, marshalContent); err != nil {
-		return "", err
+		return er.CommitUnsuccessful
 	}
-	fmt.Println("Started tracking", filePath)
-	return fileObjectId, nil
+	return SaveTracker(root, 0, marshalContent)
 }
 
-// Start tracking a file in a group
-func StartGroupTracking(groupName string, filePathList []string) error {
+// File resolved from a path argument
+type match struct {
+	path     string
+	explicit bool // named explicitly rather than found in a folder or by a pattern
+}
 
-	// Get tracker details
-	_, groupTracker, err := GetTracker(1)
-	if err != nil {
-		return err
+// Resolves file, folder and glob pattern arguments to the canonical paths of the files they denote
+func expandPaths(root string, paths []string, recursive bool) ([]match, error) {
+	var matches []match
+	seen := make(map[string]bool)
+	add := func(filePath string, explicit bool) {
+		if !seen[filePath] {
+			seen[filePath] = true
+			matches = append(matches, match{path: filePath, explicit: explicit})
+		}
 	}
 
-	for _, filePath := range filePathList {
-		if utl.FolderExists(filePath) {
-			err := filepath.Walk(filePath, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if info.IsDir() && path != filePath {
-					return filepath.SkipDir
-				}
-				if !info.IsDir() {
-					groupTracker, err = fileTracker(path, groupName, groupTracker)
-					if err != nil && !errors.Is(err, er.BinFileErr) {
-						return err
-					}
-				}
-				return nil
-			})
+	for _, filePath := range paths {
+		source, ok, err := newSource(root, filePath, recursive)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			files, err := source.Files(root)
 			if err != nil {
-				return err
+				return nil, err
+			}
+			if len(files) == 0 && source.Pattern {
+				return nil, fmt.Errorf("%w: %s", er.NoMatch, filePath)
+			}
+			for _, file := range files {
+				add(file, false)
 			}
 		} else {
-			groupTracker, err = fileTracker(filePath, groupName, groupTracker)
+			// Identify the file by its canonical path
+			file, err := utl.Canonical(root, filePath)
 			if err != nil {
-				return err
+				return nil, err
 			}
+			add(file, true)
 		}
 	}
+	return matches, nil
+}
 
-	marshalContent, err := json.MarshalIndent(groupTracker, "", " ")
+// Returns the source denoted by a folder or glob pattern, false for paths of files
+func newSource(root, filePath string, recursive bool) (GroupSource, bool, error) {
+	if utl.HasGlobMeta(filePath) && !utl.FileExists(utl.WorkPath(root, filePath)) {
+		pattern, err := utl.Canonical(root, filePath)
+		if err != nil {
+			return GroupSource{}, false, err
+		}
+		return GroupSource{Path: pattern, Pattern: true}, true, nil
+	}
+	if utl.FolderExists(utl.WorkPath(root, filePath)) {
+		// The folder is canonicalized through an entry inside it, as the repository root itself is no valid file
+		entry, err := utl.Canonical(root, filepath.Join(filePath, "_"))
+		if err != nil {
+			return GroupSource{}, false, err
+		}
+		return GroupSource{Path: path.Dir(entry), Recursive: recursive}, true, nil
+	}
+	return GroupSource{}, false, nil
+}
+
+// Returns the canonical paths of the files on disk matched by the source, sorted.
+// A folder that no longer exists matches no files.
+func (s GroupSource) Files(root string) ([]string, error) {
+	if s.Pattern {
+		files, err := utl.Glob(root, s.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", er.InvalidPattern, s.Path)
+		}
+		return files, nil
+	}
+	folder := s.Path
+	if folder == "." {
+		folder = ""
+	}
+	files, err := utl.ListFiles(root, folder, s.Recursive)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return files, err
+}
+
+// Records the folders and glob patterns among paths as sources of the group, new files matching them
+// are tracked when the group is committed. Paths of files are no sources. Returns the sources added.
+func AddGroupSources(root, groupName string, paths []string, recursive bool) ([]GroupSource, error) {
+	_, groupTracker, err := GetTracker(root, 1)
 	if err != nil {
-		return er.CommitUnsuccessful
+		return nil, err
+	}
+	groupId := utl.Hasher(groupName)
+	gr, ok := groupTracker[groupId]
+	if !ok {
+		return nil, er.InvalidGroup
 	}
 
-	// Update the tracker
-	if err = SaveTracker(1, marshalContent); err != nil {
-		return err
+	var added []GroupSource
+	for _, filePath := range paths {
+		source, ok, err := newSource(root, filePath, recursive)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || slices.Contains(gr.Sources, source) {
+			continue
+		}
+		gr.Sources = append(gr.Sources, source)
+		added = append(added, source)
 	}
-	return nil
+	if len(added) == 0 {
+		return nil, nil
+	}
+	groupTracker[groupId] = gr
+
+	marshalContent, err := json.MarshalIndent(groupTracker, "", " ")
+	if err != nil {
+		return nil, er.CommitUnsuccessful
+	}
+	if err = SaveTracker(root, 1, marshalContent); err != nil {
+		return nil, err
+	}
+	return added, nil
 }
 
-func fileTracker(filePath string, groupName string, groupTracker GroupTrackerSchema) (GroupTrackerSchema, error) {
+// Start tracking files in a group, paths may be files, folders or glob patterns, see StartTrackingPaths.
+// Returns the paths of the files added to the group.
+func StartGroupTracking(root, groupName string, filePathList []string, recursive bool) ([]string, error) {
+	matches, err := expandPaths(root, filePathList, recursive)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get tracker details
-	tracker, _, err := GetTracker(0)
+	tracker, groupTracker, err := getTrackers(root)
 	if err != nil {
-		return groupTracker, err
+		return nil, err
 	}
-	fileId := utl.Hasher(filePath)
 	groupId := utl.Hasher(groupName)
-	f, ok := tracker[fileId]
-	if ok { // If the file is already tracked, get the current version and update the group tracker
-		val, ok := groupTracker[groupId]
-		if !ok {
-			return groupTracker, er.InvalidGroup
+	gr, ok := groupTracker[groupId]
+	if !ok {
+		return nil, er.InvalidGroup
+	}
+
+	var trackedFiles []string
+	for _, m := range matches {
+		if _, ok := gr.Versions[gr.Current].Files[utl.Hasher(m.path)]; ok {
+			if m.explicit {
+				return nil, fmt.Errorf("File %s is already tracked in group %s", m.path, groupName)
+			}
+			continue
 		}
-		_, ok = val.Versions[val.Current].Files[fileId]
-		if ok {
-			return groupTracker, fmt.Errorf("File %s is already tracked in group %s", filePath, groupName)
+		if err = fileTracker(root, m.path, gr, tracker); err != nil {
+			return nil, err
 		}
+		trackedFiles = append(trackedFiles, m.path)
+	}
+
+	// Update the trackers, the file tracker first as the group refers to its entries
+	if err = saveFileTracker(root, tracker); err != nil {
+		return nil, err
+	}
+	marshalContent, err := json.MarshalIndent(groupTracker, "", " ")
+	if err != nil {
+		return nil, er.CommitUnsuccessful
+	}
+	if err = SaveTracker(root, 1, marshalContent); err != nil {
+		return nil, err
+	}
+	return trackedFiles, nil
+}
+
+// Returns both trackers of the repository
+func getTrackers(root string) (TrackerSchema, GroupTrackerSchema, error) {
+	tracker, _, err := GetTracker(root, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, groupTracker, err := GetTracker(root, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tracker, groupTracker, nil
+}
+
+// Adds the file at its current version to the current commit of the group, the file is tracked first if needed
+func fileTracker(root, filePath string, gr GroupTracker, tracker TrackerSchema) error {
+	fileId := utl.Hasher(filePath)
+	f, ok := tracker[fileId]
+	if ok { // If the file is already tracked, get the current version and update the group tracker
 		var commitNumber int
 
 		// if current version of the file is a base file
@@ -306,30 +587,52 @@ func fileTracker(filePath string, groupName string, groupTracker GroupTrackerSch
 				}
 			}
 		}
-		val.Versions[val.Current].Files[fileId] = FileDetails{
+		gr.Versions[gr.Current].Files[fileId] = FileDetails{
 			FileName:     filePath,
 			CommitNumber: commitNumber,
 			FileObjID:    f.Current,
 		}
-		groupTracker[groupId] = val
-		fmt.Println("Started tracking", filePath, "for group", groupName)
 	} else { // If file is not tracked, then track the file first
-		fileObjectId, err := StartTracking(filePath)
+		fileObjectId, err := addFile(root, tracker, filePath)
 		if err != nil {
-			return groupTracker, err
-		}
-		val, ok := groupTracker[groupId]
-		if !ok {
-			return groupTracker, er.InvalidGroup
+			return err
 		}
 
 		// As the file is first time tracked, the commit id is set to -2, that indicates, in case of revert, need to revert back to base version
-		val.Versions[val.Current].Files[fileId] = FileDetails{
+		gr.Versions[gr.Current].Files[fileId] = FileDetails{
 			FileName:     filePath,
 			CommitNumber: -2,
 			FileObjID:    fileObjectId,
 		}
-		groupTracker[groupId] = val
 	}
-	return groupTracker, nil
+	return nil
+}
+
+// Returns the objects referenced by the trackers, group commits of files that are no longer tracked keep their objects too
+func Reachable(tracker TrackerSchema, groupTracker GroupTrackerSchema) map[string]bool {
+	reachable := make(map[string]bool)
+	for _, val := range tracker {
+		reachable[val.Base] = true
+		for _, version := range val.Versions {
+			reachable[version.Object()] = true
+			if version.Snapshot != "" {
+				reachable[version.Snapshot] = true
+			}
+			if version.DeltaOf != "" {
+				reachable[version.DeltaOf] = true
+			}
+		}
+	}
+	for _, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			for fileID, file := range version.Files {
+				if val, ok := tracker[fileID]; ok {
+					reachable[val.Object(file.FileObjID)] = true
+				} else if file.FileObjID != "" {
+					reachable[file.FileObjID] = true
+				}
+			}
+		}
+	}
+	return reachable
 }
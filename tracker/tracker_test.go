@@ -0,0 +1,91 @@
+package tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+)
+
+// writeFiles creates the files below root with their path as content
+func writeFiles(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestStartTrackingPaths tests tracking of folders and glob patterns
+func TestStartTrackingPaths(t *testing.T) {
+	root := newRepo(t)
+	writeFiles(t, root, "README.md", "content/index.md", "content/a/post.md", "content/a/b/deep.md", "content/a/image.txt")
+
+	// Folders are only walked into subfolders when recursive
+	tracked, err := StartTrackingPaths(root, []string{"content"}, false)
+	if err != nil || !reflect.DeepEqual(tracked, []string{"content/index.md"}) {
+		t.Fatalf("StartTrackingPaths(content) = %v, %v", tracked, err)
+	}
+
+	// Files matched by patterns that are already tracked are skipped
+	tracked, err = StartTrackingPaths(root, []string{"content/**/*.md"}, false)
+	want := []string{"content/a/b/deep.md", "content/a/post.md"}
+	if err != nil || !reflect.DeepEqual(tracked, want) {
+		t.Fatalf("StartTrackingPaths(content/**/*.md) = %v, %v; want %v", tracked, err, want)
+	}
+
+	tracked, err = StartTrackingPaths(root, []string{"."}, true)
+	want = []string{"README.md", "content/a/image.txt"}
+	if err != nil || !reflect.DeepEqual(tracked, want) {
+		t.Fatalf("StartTrackingPaths(.) = %v, %v; want %v", tracked, err, want)
+	}
+	tracker, _, err := GetTracker(root, 0)
+	if err != nil || len(tracker) != 5 {
+		t.Fatalf("expected 5 tracked files, got %d: %v", len(tracker), err)
+	}
+
+	// Explicitly named files must not be tracked yet, patterns must match
+	if _, err := StartTrackingPaths(root, []string{"README.md"}, false); !errors.Is(err, er.FileTracked) {
+		t.Errorf("expected FileTracked, got %v", err)
+	}
+	if _, err := StartTrackingPaths(root, []string{"docs/*.md"}, false); !errors.Is(err, er.NoMatch) {
+		t.Errorf("expected NoMatch, got %v", err)
+	}
+}
+
+// TestStartGroupTracking tests adding files to a group by pattern
+func TestStartGroupTracking(t *testing.T) {
+	root := newRepo(t)
+	writeFiles(t, root, "content/index.md", "content/a/post.md", "content/a/notes.txt")
+	groupTracker := GroupTrackerSchema{utl.Hasher("site"): {GroupName: "site", Current: "init", Versions: map[string]GroupVersionDetails{"init": {Files: map[string]FileDetails{}}}}}
+	content, err := json.Marshal(groupTracker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveTracker(root, 1, content); err != nil {
+		t.Fatal(err)
+	}
+
+	tracked, err := StartGroupTracking(root, "site", []string{"content/**/*.md"}, false)
+	want := []string{"content/a/post.md", "content/index.md"}
+	if err != nil || !reflect.DeepEqual(tracked, want) {
+		t.Fatalf("StartGroupTracking() = %v, %v; want %v", tracked, err, want)
+	}
+	tracked, err = StartGroupTracking(root, "site", []string{"content"}, true)
+	if err != nil || !reflect.DeepEqual(tracked, []string{"content/a/notes.txt"}) {
+		t.Fatalf("StartGroupTracking(content) = %v, %v", tracked, err)
+	}
+	if _, err := StartGroupTracking(root, "site", []string{"content/index.md"}, false); err == nil {
+		t.Errorf("expected an error for a file already in the group")
+	}
+}
@@ -0,0 +1,169 @@
+package untrack
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mainak55512/qwe/gc"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// Stops tracking a file and removes its history along with the objects no other file shares.
+// A file tracked in a group is refused unless force is set, in which case it is removed from every commit of those groups.
+// The file itself is left untouched.
+func Untrack(root, filePath string, force bool) (gc.Result, error) {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return gc.Result{}, err
+	}
+
+	tracker, groupTracker, err := trackers(root)
+	if err != nil {
+		return gc.Result{}, err
+	}
+	fileID := utl.Hasher(filePath)
+	val, ok := tracker[fileID]
+	if !ok {
+		return gc.Result{}, er.FileNotTracked
+	}
+
+	// Groups in which any commit refers to the file
+	var groups []string
+	for _, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			if _, ok := version.Files[fileID]; ok {
+				groups = append(groups, gr.GroupName)
+				break
+			}
+		}
+	}
+	if len(groups) > 0 && !force {
+		sort.Strings(groups)
+		return gc.Result{}, fmt.Errorf("%w: %s is tracked in %s", er.FileInGroup, filePath, strings.Join(groups, ", "))
+	}
+
+	// Every object of the history, keyframes and the full copies binary deltas are based on included
+	candidates := []string{val.Base}
+	for _, version := range val.Versions {
+		candidates = append(candidates, version.Object(), version.Snapshot, version.DeltaOf)
+	}
+	for groupID, gr := range groupTracker {
+		for _, version := range gr.Versions {
+			delete(version.Files, fileID)
+		}
+		groupTracker[groupID] = gr
+	}
+	delete(tracker, fileID)
+
+	if err := save(root, tracker, groupTracker); err != nil {
+		return gc.Result{}, err
+	}
+	if err := dropTags(root, fileID, ""); err != nil {
+		return gc.Result{}, err
+	}
+	return gc.Prune(root, candidates)
+}
+
+// Deletes a group along with its commits, the files of the group stay tracked on their own.
+// Group commits only refer to versions of tracked files, so no object becomes unreachable.
+func DeleteGroup(root, groupName string) error {
+	tracker, groupTracker, err := trackers(root)
+	if err != nil {
+		return err
+	}
+	groupID := utl.Hasher(groupName)
+	if _, ok := groupTracker[groupID]; !ok {
+		return er.InvalidGroup
+	}
+	delete(groupTracker, groupID)
+
+	if err := save(root, tracker, groupTracker); err != nil {
+		return err
+	}
+	return dropTags(root, "", groupID)
+}
+
+// Removes a file from every commit of a group, the file stays tracked on its own along with its versions
+func UntrackFromGroup(root, groupName, filePath string) error {
+
+	// Identify the file by its canonical path
+	filePath, err := utl.Canonical(root, filePath)
+	if err != nil {
+		return err
+	}
+
+	tracker, groupTracker, err := trackers(root)
+	if err != nil {
+		return err
+	}
+	groupID := utl.Hasher(groupName)
+	gr, ok := groupTracker[groupID]
+	if !ok {
+		return er.InvalidGroup
+	}
+
+	fileID := utl.Hasher(filePath)
+	found := false
+	for _, version := range gr.Versions {
+		if _, ok := version.Files[fileID]; ok {
+			found = true
+			delete(version.Files, fileID)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s is not tracked in group %s", er.FileNotTracked, filePath, groupName)
+	}
+	groupTracker[groupID] = gr
+	return save(root, tracker, groupTracker)
+}
+
+// Returns both trackers of the repository
+func trackers(root string) (tr.TrackerSchema, tr.GroupTrackerSchema, error) {
+	tracker, _, err := tr.GetTracker(root, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, groupTracker, err := tr.GetTracker(root, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tracker, groupTracker, nil
+}
+
+// Saves both trackers, the group tracker first so that no group refers to a file missing from the file tracker
+func save(root string, tracker tr.TrackerSchema, groupTracker tr.GroupTrackerSchema) error {
+	marshalContent, err := json.MarshalIndent(groupTracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	if err = tr.SaveTracker(root, 1, marshalContent); err != nil {
+		return err
+	}
+	marshalContent, err = json.MarshalIndent(tracker, "", " ")
+	if err != nil {
+		return er.TrackerWriteErr
+	}
+	return tr.SaveTracker(root, 0, marshalContent)
+}
+
+// Forgets the tags of an untracked file or a deleted group, the other id is left empty
+func dropTags(root, fileID, groupID string) error {
+	meta, err := tr.GetMeta(root)
+	if err != nil {
+		return err
+	}
+	_, fileTagged := meta.Tags.Files[fileID]
+	_, groupTagged := meta.Tags.Groups[groupID]
+	if !fileTagged && !groupTagged {
+		return nil
+	}
+	delete(meta.Tags.Files, fileID)
+	delete(meta.Tags.Groups, groupID)
+	return tr.SaveMeta(root, meta)
+}
@@ -0,0 +1,169 @@
+package untrack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cm "github.com/mainak55512/qwe/commit"
+	in "github.com/mainak55512/qwe/initializer"
+	er "github.com/mainak55512/qwe/qwerror"
+	utl "github.com/mainak55512/qwe/qweutils"
+	tr "github.com/mainak55512/qwe/tracker"
+)
+
+// newRepo creates a repository with two files of identical base content, big.bin is tracked in a group too
+func newRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	for _, name := range []string{"big.bin", "copy.bin"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("same\x00content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tr.StartTracking(root, name); err != nil {
+			t.Fatalf("StartTracking(%s) failed: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "big.bin"), []byte("changed\x00content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cm.CommitUnit(root, "big.bin", "change"); err != nil {
+		t.Fatalf("CommitUnit() failed: %v", err)
+	}
+	if err := in.GroupInit(root, "assets"); err != nil {
+		t.Fatalf("GroupInit() failed: %v", err)
+	}
+	if _, err := tr.StartGroupTracking(root, "assets", []string{"big.bin"}, false); err != nil {
+		t.Fatalf("StartGroupTracking() failed: %v", err)
+	}
+	return root
+}
+
+// TestUntrack tests that a grouped file needs force and that shared objects survive
+func TestUntrack(t *testing.T) {
+	root := newRepo(t)
+	tracker, _, _ := tr.GetTracker(root, 0)
+	big := tracker[utl.Hasher("big.bin")]
+
+	if _, err := Untrack(root, "big.bin", false); !errors.Is(err, er.FileInGroup) {
+		t.Fatalf("expected FileInGroup error, got: %v", err)
+	}
+
+	result, err := Untrack(root, "big.bin", true)
+	if err != nil {
+		t.Fatalf("Untrack() failed: %v", err)
+	}
+	if len(result.Removed) != 1 || result.ReclaimedBytes == 0 {
+		t.Errorf("expected only the committed object to be removed, got %+v", result)
+	}
+	if !utl.FileExists(utl.ObjectPath(root, big.Base)) {
+		t.Errorf("base object shared with copy.bin was removed")
+	}
+	if utl.FileExists(utl.ObjectPath(root, big.Versions[0].ObjID)) {
+		t.Errorf("object of the untracked file was kept")
+	}
+	if !utl.FileExists(filepath.Join(root, "big.bin")) {
+		t.Errorf("untracked file was removed from disk")
+	}
+
+	tracker, groupTracker, err := trackers(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tracker[utl.Hasher("big.bin")]; ok {
+		t.Errorf("tracker entry was kept")
+	}
+	for _, version := range groupTracker[utl.Hasher("assets")].Versions {
+		if len(version.Files) != 0 {
+			t.Errorf("group still refers to the untracked file: %+v", version.Files)
+		}
+	}
+}
+
+// TestUntrack_Keyframes tests that the keyframes of an untracked text file are removed along with its versions
+func TestUntrack_Keyframes(t *testing.T) {
+	root := t.TempDir()
+	notes := filepath.Join(root, "notes.txt")
+	if err := in.Init(root); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	if err := tr.SaveMeta(root, tr.Meta{SchemaVersion: tr.SchemaVersion, KeyframeInterval: 2}); err != nil {
+		t.Fatal(err)
+	}
+	content := "a\n"
+	if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.StartTracking(root, "notes.txt"); err != nil {
+		t.Fatalf("StartTracking() failed: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		content += "more\n"
+		if err := os.WriteFile(notes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := cm.CommitUnit(root, "notes.txt", "more"); err != nil {
+			t.Fatalf("CommitUnit() failed: %v", err)
+		}
+	}
+	tracker, _, _ := tr.GetTracker(root, 0)
+	var snapshots []string
+	for _, version := range tracker[utl.Hasher("notes.txt")].Versions {
+		if version.Snapshot != "" {
+			snapshots = append(snapshots, version.Snapshot)
+		}
+	}
+	if len(snapshots) == 0 {
+		t.Fatal("no keyframe was stored")
+	}
+
+	if _, err := Untrack(root, "notes.txt", false); err != nil {
+		t.Fatalf("Untrack() failed: %v", err)
+	}
+	for _, snapshot := range snapshots {
+		if utl.FileExists(utl.ObjectPath(root, snapshot)) {
+			t.Errorf("keyframe %s of the untracked file was kept", snapshot)
+		}
+	}
+	if entries, _ := os.ReadDir(utl.QwePath(root, "_object")); len(entries) != 0 {
+		t.Errorf("objects left behind: %v", entries)
+	}
+}
+
+// TestUntrackFromGroup_DeleteGroup tests that files stay tracked when they leave or lose their group
+func TestUntrackFromGroup_DeleteGroup(t *testing.T) {
+	root := newRepo(t)
+
+	if err := UntrackFromGroup(root, "assets", "copy.bin"); !errors.Is(err, er.FileNotTracked) {
+		t.Errorf("expected FileNotTracked for a file outside the group, got: %v", err)
+	}
+	if err := UntrackFromGroup(root, "assets", "big.bin"); err != nil {
+		t.Fatalf("UntrackFromGroup() failed: %v", err)
+	}
+	if _, err := Untrack(root, "big.bin", false); err != nil {
+		t.Errorf("file removed from its group still needs force: %v", err)
+	}
+
+	if err := DeleteGroup(root, "assets"); err != nil {
+		t.Fatalf("DeleteGroup() failed: %v", err)
+	}
+	if err := DeleteGroup(root, "assets"); !errors.Is(err, er.InvalidGroup) {
+		t.Errorf("expected InvalidGroup for a deleted group, got: %v", err)
+	}
+	tracker, _, _ := tr.GetTracker(root, 0)
+	val, ok := tracker[utl.Hasher("copy.bin")]
+	if !ok {
+		t.Fatalf("copy.bin is no longer tracked")
+	}
+
+	// The versions the group referred to still belong to the file
+	for _, objID := range append([]string{val.Base}, val.Object(val.Current)) {
+		if !utl.FileExists(utl.ObjectPath(root, objID)) {
+			t.Errorf("object %s of copy.bin was removed", objID)
+		}
+	}
+}